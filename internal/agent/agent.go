@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"errors"
+	"time"
+
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// The agent is a background process that keeps one vault unlocked so CLI
+// commands can use it without the master password. It listens on a Unix
+// socket beside the vault file, readable only by its owner, and speaks
+// newline-delimited JSON: one Request per line, answered by one Response.
+
+const (
+	socketSuffix       = ".sock"
+	SocketPermissions  = 0600 // Owner read/write only
+	DefaultIdleTimeout = 15 * time.Minute
+)
+
+// Request operations
+const (
+	OpStatus = "status"
+	OpList   = "list"
+	OpGet    = "get"
+	OpUse    = "use" // A get that settles ambiguous refs by rank and records the use
+	OpAdd    = "add"
+	OpLock   = "lock"
+)
+
+var (
+	ErrNotRunning  = errors.New("no agent running")
+	ErrRunning     = errors.New("agent already running")
+	ErrUnsupported = errors.New("vault agent is not supported on this platform")
+	ErrPeer        = errors.New("connection from another user refused")
+)
+
+// Request is a single call to the agent
+type Request struct {
+	Op     string                `json:"op"`
+	Query  string                `json:"query,omitempty"`  // list
+	Ref    string                `json:"ref,omitempty"`    // get, use: ID, path or title
+	Entry  *models.PasswordEntry `json:"entry,omitempty"`  // add
+	Folder string                `json:"folder,omitempty"` // add: path, created if missing
+}
+
+// Response answers a Request; Error is set if it failed
+type Response struct {
+	Entries []models.PasswordEntry `json:"entries,omitempty"`
+	Folders []models.Folder        `json:"folders,omitempty"` // For the paths of Entries
+	Status  *Status                `json:"status,omitempty"`
+	Error   *Error                 `json:"error,omitempty"`
+}
+
+// Status describes a running agent
+type Status struct {
+	PID         int           `json:"pid"`
+	Vault       string        `json:"vault"`
+	IdleTimeout time.Duration `json:"idle_timeout"` // 0 never locks on idle
+	LocksAt     time.Time     `json:"locks_at"`     // Zero if IdleTimeout is 0
+}
+
+// Error is a failure reported by the agent. It matches the sentinel error
+// it was created from with errors.Is, so callers can classify it as if the
+// operation had run locally.
+type Error struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	for _, known := range errorCodes {
+		if known.code == e.Code {
+			return target == known.err
+		}
+	}
+	return false
+}
+
+// errorCodes are the sentinel errors that survive the trip over the socket
+var errorCodes = []struct {
+	code string
+	err  error
+}{
+	{"not_found", models.ErrNotFound},
+	{"ambiguous", models.ErrAmbiguous},
+	{"vault_not_found", storage.ErrVaultNotFound},
+	{"wrong_password", storage.ErrWrongPassword},
+	{"corrupt", storage.ErrCorrupt},
+	{"locked", storage.ErrLocked},
+	{"rekeyed", storage.ErrExternalRekey},
+}
+
+// newError converts err for the wire
+func newError(err error) *Error {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return &Error{Code: known.code, Message: err.Error()}
+		}
+	}
+	return &Error{Code: "error", Message: err.Error()}
+}
+
+// SocketPath returns the agent socket location for the given vault file
+func SocketPath(vaultPath string) string {
+	return vaultPath + socketSuffix
+}
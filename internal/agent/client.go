@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"vault/internal/models"
+)
+
+const dialTimeout = 2 * time.Second
+
+// Client is a connection to a running agent
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// Dial connects to the agent serving the given vault file. It returns an
+// error wrapping ErrNotRunning if there is none.
+func Dial(vaultPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(vaultPath), dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// call sends req and waits for the response
+func (c *Client) call(req Request) (*Response, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(append(data, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from agent: %w", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response from agent: %w", err)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return &resp, nil
+}
+
+// Status reports on the running agent
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.call(Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, errors.New("agent sent no status")
+	}
+	return resp.Status, nil
+}
+
+// List returns the entries matching query, or all entries if it is empty,
+// along with the vault's folders
+func (c *Client) List(query string) ([]models.PasswordEntry, []models.Folder, error) {
+	resp, err := c.call(Request{Op: OpList, Query: query})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp.Entries, resp.Folders, nil
+}
+
+// Get returns the entry with the given ID, path or title, along with the
+// vault's folders
+func (c *Client) Get(ref string) (*models.PasswordEntry, []models.Folder, error) {
+	resp, err := c.call(Request{Op: OpGet, Ref: ref})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Entries) != 1 {
+		return nil, nil, errors.New("agent sent no entry")
+	}
+	return &resp.Entries[0], resp.Folders, nil
+}
+
+// Use resolves ref like Get, except that an ambiguous ref is settled by
+// ranking the entries it fits, and records that the entry was used
+func (c *Client) Use(ref string) (*models.PasswordEntry, []models.Folder, error) {
+	resp, err := c.call(Request{Op: OpUse, Ref: ref})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Entries) != 1 {
+		return nil, nil, errors.New("agent sent no entry")
+	}
+	return &resp.Entries[0], resp.Folders, nil
+}
+
+// Add stores a new entry built from the fields of entry in the folder at
+// path, creating it if missing, and returns the entry as saved along with
+// the vault's folders
+func (c *Client) Add(entry *models.PasswordEntry, folder string) (*models.PasswordEntry, []models.Folder, error) {
+	resp, err := c.call(Request{Op: OpAdd, Entry: entry, Folder: folder})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Entries) != 1 {
+		return nil, nil, errors.New("agent sent no entry")
+	}
+	return &resp.Entries[0], resp.Folders, nil
+}
+
+// Lock makes the agent forget the key and exit
+func (c *Client) Lock() error {
+	_, err := c.call(Request{Op: OpLock})
+	return err
+}
@@ -0,0 +1,321 @@
+//go:build linux || darwin
+
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"vault/internal/crypto"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// Server holds an unlocked vault session and answers requests for it
+type Server struct {
+	store    *storage.Storage
+	session  *crypto.Session
+	idle     time.Duration
+	listener *net.UnixListener
+
+	mu      sync.Mutex // Serializes requests and guards the fields below
+	timer   *time.Timer
+	locksAt time.Time
+	locked  bool
+	done    chan struct{}
+}
+
+// NewServer creates an agent for an unlocked session. With a non-zero idle
+// timeout the agent locks itself after that long without a request.
+func NewServer(store *storage.Storage, session *crypto.Session, idle time.Duration) *Server {
+	return &Server{
+		store:   store,
+		session: session,
+		idle:    idle,
+		done:    make(chan struct{}),
+	}
+}
+
+// SocketPath returns the socket this server listens on
+func (s *Server) SocketPath() string {
+	return SocketPath(s.store.GetVaultPath())
+}
+
+// Listen creates the agent socket with owner-only permissions, replacing a
+// stale socket left by an agent that did not shut down cleanly
+func (s *Server) Listen() error {
+	path := s.SocketPath()
+
+	if _, err := os.Lstat(path); err == nil {
+		if client, err := Dial(s.store.GetVaultPath()); err == nil {
+			client.Close()
+			return fmt.Errorf("%w on %s", ErrRunning, path)
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove stale agent socket: %w", err)
+		}
+	}
+
+	// Create the socket with no group or other access from the start
+	oldMask := syscall.Umask(0077)
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	syscall.Umask(oldMask)
+	if err != nil {
+		return fmt.Errorf("failed to listen on agent socket: %w", err)
+	}
+	if err := os.Chmod(path, SocketPermissions); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to secure agent socket: %w", err)
+	}
+
+	s.listener = listener
+	s.mu.Lock()
+	s.touch()
+	s.mu.Unlock()
+	return nil
+}
+
+// Serve answers connections until the agent is locked
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.AcceptUnix()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("agent accept failed: %w", err)
+		}
+		go s.handleConn(conn)
+	}
+}
+
+// Done is closed once the agent has locked
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+// Lock wipes the session key and stops listening. It is safe to call more
+// than once.
+func (s *Server) Lock() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lock()
+}
+
+func (s *Server) lock() {
+	if s.locked {
+		return
+	}
+	s.locked = true
+
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.session.Wipe()
+	if s.listener != nil {
+		s.listener.Close() // Also removes the socket file
+	}
+	close(s.done)
+}
+
+// touch restarts the idle timer; the caller holds mu
+func (s *Server) touch() {
+	if s.idle <= 0 {
+		return
+	}
+	s.locksAt = time.Now().Add(s.idle)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.idle, s.Lock)
+	} else {
+		s.timer.Reset(s.idle)
+	}
+}
+
+// handleConn serves requests from one client connection
+func (s *Server) handleConn(conn *net.UnixConn) {
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != os.Getuid() {
+		s.reply(conn, Response{Error: newError(ErrPeer)})
+		return
+	}
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp.Error = newError(fmt.Errorf("invalid request: %v", err))
+		} else {
+			resp = s.handle(req)
+		}
+
+		if err := s.reply(conn, resp); err != nil {
+			return
+		}
+		if req.Op == OpLock {
+			s.Lock()
+			return
+		}
+	}
+}
+
+func (s *Server) reply(conn net.Conn, resp Response) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(append(data, '\n'))
+	return err
+}
+
+// handle runs a single request
+func (s *Server) handle(req Request) Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.locked {
+		return Response{Error: newError(ErrNotRunning)}
+	}
+	if req.Op != OpStatus {
+		s.touch()
+	}
+
+	var resp Response
+	var err error
+	switch req.Op {
+	case OpStatus:
+		resp.Status = &Status{
+			PID:         os.Getpid(),
+			Vault:       s.store.GetVaultPath(),
+			IdleTimeout: s.idle,
+			LocksAt:     s.locksAt,
+		}
+	case OpList:
+		resp.Entries, resp.Folders, err = s.list(req.Query)
+	case OpGet:
+		var entry *models.PasswordEntry
+		if entry, resp.Folders, err = s.get(req.Ref); err == nil {
+			resp.Entries = []models.PasswordEntry{*entry}
+		}
+	case OpUse:
+		var entry *models.PasswordEntry
+		if entry, resp.Folders, err = s.use(req.Ref); err == nil {
+			resp.Entries = []models.PasswordEntry{*entry}
+		}
+	case OpAdd:
+		var entry *models.PasswordEntry
+		if entry, resp.Folders, err = s.add(req.Entry, req.Folder); err == nil {
+			resp.Entries = []models.PasswordEntry{*entry}
+		}
+	case OpLock:
+		// Answered first, then handled by the connection
+	default:
+		err = fmt.Errorf("unknown request %q", req.Op)
+	}
+
+	if err != nil {
+		resp.Error = newError(err)
+	}
+	return resp
+}
+
+// load reads the current vault with the held session. A vault re-keyed by
+// another process can no longer be opened with it, so the agent locks.
+func (s *Server) load() (*models.Vault, error) {
+	vault, err := s.store.Reload(s.session)
+	if errors.Is(err, storage.ErrExternalRekey) {
+		s.lock()
+	}
+	return vault, err
+}
+
+func (s *Server) list(query string) ([]models.PasswordEntry, []models.Folder, error) {
+	vault, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := vault.SearchEntries(query, s.store.LoadUsage(s.session))
+	return entries, vault.Folders, err
+}
+
+func (s *Server) get(ref string) (*models.PasswordEntry, []models.Folder, error) {
+	vault, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := vault.FindEntry(ref)
+	return entry, vault.Folders, err
+}
+
+// use resolves ref for a command about to use the entry's secrets, letting
+// its ranking settle an ambiguous ref, and records the use
+func (s *Server) use(ref string) (*models.PasswordEntry, []models.Folder, error) {
+	vault, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := vault.ResolveEntry(ref, s.store.LoadUsage(s.session))
+	if err != nil {
+		return nil, nil, err
+	}
+	// Usage only ranks searches; failing to record it fails nothing
+	s.store.RecordUse(s.session, entry.ID, time.Now())
+	return entry, vault.Folders, nil
+}
+
+func (s *Server) add(fields *models.PasswordEntry, folder string) (*models.PasswordEntry, []models.Folder, error) {
+	if fields == nil || strings.TrimSpace(fields.Title) == "" {
+		return nil, nil, errors.New("title is required")
+	}
+	// Enforce what the entry's kind requires, as adding without the agent does
+	entryFields := fields.Fields()
+	if err := entryFields.ApplySchema(); err != nil {
+		return nil, nil, err
+	}
+
+	// Hold the vault lock across the read-modify-write
+	if err := s.store.Lock(); err != nil {
+		return nil, nil, err
+	}
+	defer s.store.Unlock()
+
+	vault, err := s.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := models.NewPasswordEntry(entryFields)
+	if entry.FolderID, err = vault.MkdirAll(folder); err != nil {
+		return nil, nil, err
+	}
+	vault.AddEntry(entry)
+
+	// A merge with external edits or a failed directory sync still saved
+	// the vault
+	var conflictErr *storage.ConflictError
+	var syncErr *storage.SyncError
+	if err := s.store.SaveVault(vault, s.session); err != nil && !errors.As(err, &conflictErr) && !errors.As(err, &syncErr) {
+		return nil, nil, err
+	}
+	return entry, vault.Folders, nil
+}
@@ -0,0 +1,39 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"time"
+
+	"vault/internal/crypto"
+	"vault/internal/storage"
+)
+
+// Server is unavailable on platforms without peer credentials on Unix
+// sockets; Listen always fails with ErrUnsupported
+type Server struct {
+	store *storage.Storage
+	done  chan struct{}
+}
+
+func NewServer(store *storage.Storage, session *crypto.Session, idle time.Duration) *Server {
+	return &Server{store: store, done: make(chan struct{})}
+}
+
+func (s *Server) SocketPath() string {
+	return SocketPath(s.store.GetVaultPath())
+}
+
+func (s *Server) Listen() error {
+	return ErrUnsupported
+}
+
+func (s *Server) Serve() error {
+	return ErrUnsupported
+}
+
+func (s *Server) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Server) Lock() {}
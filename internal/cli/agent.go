@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"vault/internal/agent"
+	"vault/internal/daemon"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// agentReady is printed by a detached agent once it is listening, telling
+// the process that started it that the unlock succeeded
+const agentReady = "vault agent ready"
+
+// agentView is the structured output of `vault agent`
+type agentView struct {
+	PID                int        `json:"pid" yaml:"pid"`
+	Vault              string     `json:"vault" yaml:"vault"`
+	Socket             string     `json:"socket" yaml:"socket"`
+	IdleTimeoutSeconds int        `json:"idle_timeout_seconds" yaml:"idle_timeout_seconds"`
+	LocksAt            *time.Time `json:"locks_at" yaml:"locks_at"`
+}
+
+// runAgent handles `vault agent [--timeout D] [--foreground]`
+func runAgent(env *Env, args []string) error {
+	fs := flag.NewFlagSet("agent", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	timeout := fs.Duration("timeout", 0, "Lock after this long without a request; 0 never locks (default from config, 15m)")
+	foreground := fs.Bool("foreground", false, "Stay in the foreground until locked")
+	detached := fs.Bool("detached", false, "Run as the background agent process (internal)")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: vault agent [flags]")
+	}
+
+	store, cfg, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+	if !store.VaultExists() {
+		return fmt.Errorf("%w: %s (run vault to create one)", storage.ErrVaultNotFound, store.GetVaultPath())
+	}
+
+	idle := cfg.AgentIdleTimeout()
+	if isFlagSet(fs, "timeout") {
+		idle = *timeout
+	}
+	if idle < 0 {
+		return usagef("--timeout cannot be negative")
+	}
+
+	if client, err := agent.Dial(store.GetVaultPath()); err == nil {
+		client.Close()
+		return fmt.Errorf("%w on %s", agent.ErrRunning, agent.SocketPath(store.GetVaultPath()))
+	}
+
+	password, err := env.masterPassword(src)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return errors.New("master password cannot be empty")
+	}
+
+	if !*foreground && !*detached {
+		return env.startAgent(store, password, idle)
+	}
+
+	_, session, err := store.LoadVault(password)
+	if err != nil {
+		return err
+	}
+
+	server := agent.NewServer(store, session, idle)
+	if err := server.Listen(); err != nil {
+		session.Wipe()
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			server.Lock()
+		case <-server.Done():
+		}
+	}()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve()
+	}()
+
+	if *detached {
+		// Whoever started us stops reading once we report in
+		signal.Ignore(syscall.SIGPIPE)
+		fmt.Fprintln(env.Stdout, agentReady)
+	} else if err := env.reportAgent(store.GetVaultPath()); err != nil {
+		server.Lock()
+		return err
+	}
+
+	err = <-served
+	if !*detached && !env.structured() {
+		fmt.Fprintln(env.Stderr, "vault agent: locked")
+	}
+	return err
+}
+
+// startAgent runs the agent as a detached copy of this program, handing it
+// the master password on stdin, and waits until it has unlocked the vault
+func (env *Env) startAgent(store *storage.Storage, password string, idle time.Duration) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate vault executable: %w", err)
+	}
+
+	args := []string{"-vault", store.GetVaultPath(), "agent", "--detached", "--password-stdin", "--timeout", idle.String()}
+	if env.format != "" {
+		args = append(args, "--format", env.format)
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.Stdin = strings.NewReader(password + "\n")
+	daemon.Detach(cmd)
+
+	// The child reports readiness or its error on one pipe
+	output, writer, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+	cmd.Stdout = writer
+	cmd.Stderr = writer
+
+	err = cmd.Start()
+	writer.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+
+	reader := bufio.NewReader(output)
+	line, _ := reader.ReadString('\n')
+	if strings.TrimSpace(line) == agentReady {
+		cmd.Process.Release()
+		return env.reportAgent(store.GetVaultPath())
+	}
+
+	// The child failed and has already described why
+	rest, _ := io.ReadAll(reader)
+	env.Stderr.Write([]byte(line))
+	env.Stderr.Write(rest)
+
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); errors.As(err, &exitErr) {
+		return exitStatus(exitErr.ExitCode())
+	}
+	return errors.New("agent exited unexpectedly")
+}
+
+// reportAgent prints the status of the running agent
+func (env *Env) reportAgent(vaultPath string) error {
+	client, err := agent.Dial(vaultPath)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	status, err := client.Status()
+	if err != nil {
+		return err
+	}
+
+	view := agentView{
+		PID:                status.PID,
+		Vault:              status.Vault,
+		Socket:             agent.SocketPath(status.Vault),
+		IdleTimeoutSeconds: int(status.IdleTimeout / time.Second),
+	}
+	if !status.LocksAt.IsZero() {
+		view.LocksAt = &status.LocksAt
+	}
+
+	if env.structured() {
+		return env.emit(view)
+	}
+
+	lockNote := "never locks on idle"
+	if status.IdleTimeout > 0 {
+		lockNote = fmt.Sprintf("locks after %s idle", status.IdleTimeout)
+	}
+	fmt.Fprintf(env.Stdout, "vault agent running (PID %d) on %s; %s\n", view.PID, view.Socket, lockNote)
+	return nil
+}
+
+// runLock handles `vault lock`
+func runLock(env *Env, args []string) error {
+	fs := flag.NewFlagSet("lock", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	locked, err := env.lockAgent()
+	if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(map[string]bool{"locked": locked})
+	}
+	if locked {
+		fmt.Fprintln(env.Stdout, "vault agent locked")
+	} else {
+		fmt.Fprintln(env.Stdout, "vault agent is not running")
+	}
+	return nil
+}
+
+// lockAgent tells a running agent to lock, reporting whether there was one
+func (env *Env) lockAgent() (bool, error) {
+	store := storage.NewStorage(env.VaultPath)
+	client, err := agent.Dial(store.GetVaultPath())
+	if errors.Is(err, agent.ErrNotRunning) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	if err := client.Lock(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// agentClient connects to the agent for the vault. It returns nil if none
+// is running or a master password source was given explicitly.
+func (env *Env) agentClient(src *passwordSource) *agent.Client {
+	if src.file != "" || src.stdin {
+		return nil
+	}
+
+	store := storage.NewStorage(env.VaultPath)
+	client, err := agent.Dial(store.GetVaultPath())
+	if err != nil {
+		return nil
+	}
+	return client
+}
+
+// lookupEntry resolves ref through the agent if one is running, otherwise
+// by unlocking the vault. The vault's folders come along to name the
+// entry's folder.
+func (env *Env) lookupEntry(src *passwordSource, ref string) (*models.PasswordEntry, []models.Folder, error) {
+	if client := env.agentClient(src); client != nil {
+		defer client.Close()
+		return client.Get(ref)
+	}
+
+	u, err := env.unlock(src, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer u.close()
+
+	entry, err := u.vault.FindEntry(ref)
+	return entry, u.vault.Folders, err
+}
+
+// useEntry resolves ref like lookupEntry for a command about to use the
+// entry's secrets: when several entries fit, the one ranking best is taken
+// if it stands out, and the use is recorded to rank it higher next time
+func (env *Env) useEntry(src *passwordSource, ref string) (*models.PasswordEntry, []models.Folder, error) {
+	if client := env.agentClient(src); client != nil {
+		defer client.Close()
+		return client.Use(ref)
+	}
+
+	u, err := env.unlock(src, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer u.close()
+
+	entry, err := u.vault.ResolveEntry(ref, u.store.LoadUsage(u.session))
+	if err != nil {
+		return nil, nil, err
+	}
+	// Usage only ranks searches; failing to record it fails nothing
+	u.store.RecordUse(u.session, entry.ID, time.Now())
+	return entry, u.vault.Folders, nil
+}
+
+// searchEntries returns the entries matching query, and the vault's folders,
+// through the agent if one is running, otherwise by unlocking the vault
+func (env *Env) searchEntries(src *passwordSource, query string) ([]models.PasswordEntry, []models.Folder, error) {
+	if client := env.agentClient(src); client != nil {
+		defer client.Close()
+		return client.List(query)
+	}
+
+	u, err := env.unlock(src, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer u.close()
+
+	entries, err := u.vault.SearchEntries(query, u.store.LoadUsage(u.session))
+	return entries, u.vault.Folders, err
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"vault/internal/storage"
+)
+
+// runBackup handles `vault backup list|restore`
+func runBackup(env *Env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: vault backup list|restore [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runBackupList(env, args[1:])
+	case "restore":
+		return runBackupRestore(env, args[1:])
+	default:
+		return usagef("unknown backup command %q", args[0])
+	}
+}
+
+// backupView is the structured output schema for a backup
+type backupView struct {
+	Number    int       `json:"number" yaml:"number"`
+	Name      string    `json:"name" yaml:"name"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	Size      int64     `json:"size" yaml:"size"`
+}
+
+func runBackupList(env *Env, args []string) error {
+	fs := flag.NewFlagSet("backup list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	store, _, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+
+	backups, err := store.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		views := make([]backupView, 0, len(backups))
+		for i, backup := range backups {
+			views = append(views, backupView{Number: i + 1, Name: backup.Name, CreatedAt: backup.CreatedAt, Size: backup.Size})
+		}
+		return env.emit(views)
+	}
+
+	if len(backups) == 0 {
+		fmt.Fprintf(env.Stdout, "no backups in %s\n", store.BackupDir())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tCREATED\tSIZE\tNAME")
+	for i, backup := range backups {
+		fmt.Fprintf(w, "%d\t%s\t%d\t%s\n", i+1, backup.CreatedAt.Local().Format("2006-01-02 15:04:05"), backup.Size, backup.Name)
+	}
+	return w.Flush()
+}
+
+func runBackupRestore(env *Env, args []string) error {
+	fs := flag.NewFlagSet("backup restore", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault backup restore [--yes] <number|name>")
+	}
+
+	store, _, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+
+	backup, err := store.FindBackup(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(env.Stderr, "Replace %s with backup from %s? [y/N] ",
+			store.GetVaultPath(), backup.CreatedAt.Local().Format(time.DateTime))
+		answer, err := env.readLine()
+		if err != nil {
+			return err
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("restore cancelled")
+		}
+	}
+
+	var syncErr *storage.SyncError
+	if err := store.RestoreBackup(*backup); errors.As(err, &syncErr) {
+		fmt.Fprintf(env.Stderr, "warning: %v\n", err)
+	} else if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(map[string]string{"restored": backup.Name})
+	}
+	fmt.Fprintf(env.Stdout, "restored %s (previous vault kept as a backup)\n", backup.Name)
+	return nil
+}
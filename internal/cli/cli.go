@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"vault/internal/clipboard"
+	"vault/internal/config"
+	"vault/internal/storage"
+)
+
+// Env carries the shared state every subcommand needs
+type Env struct {
+	VaultPath string
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer
+
+	format      string // Output format chosen with --format, "" for the default
+	stdinReader *bufio.Reader
+}
+
+// command is a single CLI subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(env *Env, args []string) error
+	hidden  bool // Internal helper, left out of help output
+}
+
+// usageError marks errors caused by bad command line arguments
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// commands lists the subcommands in the order they appear in help output
+var commands = []command{
+	{name: "list", summary: "List entries, optionally filtered by a search", run: runList},
+	{name: "show", summary: "Show all fields of an entry", run: runShow},
+	{name: "get", summary: "Print a field of an entry", run: runGet},
+	{name: "add", summary: "Add an entry", run: runAdd},
+	{name: "edit", summary: "Change fields of an entry", run: runEdit},
+	{name: "rm", summary: "Move an entry to the trash", run: runRemove},
+	{name: "trash", summary: "List, restore or purge deleted entries", run: runTrash},
+	{name: "otp", summary: "Print the current one-time code of an entry", run: runOTP},
+	{name: "history", summary: "List or restore previous values of an entry", run: runHistory},
+	{name: "generate", summary: "Generate a random password", run: runGenerate},
+	{name: "kdf", summary: "Show or calibrate key derivation parameters", run: runKDF},
+	{name: "passwd", summary: "Change the master password and re-encrypt backups", run: runPasswd},
+	{name: "folder", summary: "List, add, rename or delete folders", run: runFolder},
+	{name: "backup", summary: "List or restore vault backups", run: runBackup},
+	{name: "agent", summary: "Start an agent that keeps the vault unlocked", run: runAgent},
+	{name: "lock", summary: "Lock the running agent", run: runLock},
+	{name: clipboard.ClearCommand, summary: "Clear a copied secret from the clipboard", run: runClipboardClear, hidden: true},
+}
+
+// Run executes the subcommand named by args[0] and returns the process exit code
+func Run(vaultPath string, args []string) int {
+	env := &Env{
+		VaultPath: vaultPath,
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(env.Stderr, "vault: missing command")
+		printCommands(env)
+		return ExitUsage
+	}
+
+	cmd := lookup(args[0])
+	if cmd == nil {
+		fmt.Fprintf(env.Stderr, "vault: unknown command %q\n", args[0])
+		printCommands(env)
+		return ExitUsage
+	}
+
+	if err := cmd.run(env, args[1:]); err != nil {
+		return env.writeError(cmd.name, err)
+	}
+	return ExitOK
+}
+
+// printCommands lists the available subcommands on stderr
+func printCommands(env *Env) {
+	fmt.Fprintln(env.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		if cmd.hidden {
+			continue
+		}
+		fmt.Fprintf(env.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+func lookup(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+// openStorage returns the vault storage and its config, with the configured
+// KDF and backup policy applied
+func (env *Env) openStorage() (*storage.Storage, *config.Config, error) {
+	store := storage.NewStorage(env.VaultPath)
+
+	cfg, err := config.Load(config.Path(store.GetVaultPath()))
+	if err != nil {
+		return nil, nil, err
+	}
+	store.SetKDFParams(cfg.KDFParams())
+	store.SetBackupPolicy(cfg.BackupPolicy())
+	store.SetHistoryPolicy(cfg.HistoryPolicy())
+	store.SetTrashRetention(cfg.TrashRetention())
+
+	return store, cfg, nil
+}
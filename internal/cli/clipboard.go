@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"vault/internal/clipboard"
+)
+
+// runClipboardClear is the detached helper started after a copy. It reads
+// the fingerprint of the copied value from stdin, waits, and clears the
+// clipboard if it still holds that value.
+func runClipboardClear(env *Env, args []string) error {
+	fs := flag.NewFlagSet(clipboard.ClearCommand, flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	after := fs.Duration("after", 30*time.Second, "Delay before clearing")
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	line, err := env.readLine()
+	if err != nil {
+		return fmt.Errorf("failed to read fingerprint: %w", err)
+	}
+	fingerprint := strings.TrimSpace(line)
+	if fingerprint == "" {
+		return usagef("missing fingerprint on stdin")
+	}
+
+	time.Sleep(*after)
+	_, err = clipboard.ClearIfUnchanged(fingerprint)
+	return err
+}
@@ -0,0 +1,633 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"vault/internal/models"
+	"vault/internal/otp"
+)
+
+// entryFields are the fields `vault get --field` can print
+var entryFields = []string{"password", "username", "title", "url", "notes", "id"}
+
+// fieldValue returns the named field of an entry
+func fieldValue(entry *models.PasswordEntry, field string) (string, error) {
+	switch field {
+	case "password":
+		return entry.Password, nil
+	case "username":
+		return entry.Username, nil
+	case "title":
+		return entry.Title, nil
+	case "url":
+		return entry.URL, nil
+	case "notes":
+		return entry.Notes, nil
+	case "id":
+		return entry.ID, nil
+	default:
+		if detail, ok := entry.Schema().Field(field); ok {
+			return entry.Value(detail.Key), nil
+		}
+		if custom, ok := entry.CustomField(field); ok {
+			return custom.Value, nil
+		}
+		return "", usagef("unknown field %q (want one of %s, a field of the entry's kind, or a custom field name)", field, strings.Join(entryFields, ", "))
+	}
+}
+
+// runGet handles `vault get <title|id> [--field NAME]`
+func runGet(env *Env, args []string) error {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	field := fs.String("field", "password", "Field to print: "+strings.Join(entryFields, ", ")+", a detail such as number or private_key, or a custom field name")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault get [flags] <title|id>")
+	}
+
+	entry, _, err := env.useEntry(src, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	value, err := fieldValue(entry, *field)
+	if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(map[string]string{"id": entry.ID, "field": *field, "value": value})
+	}
+	fmt.Fprintln(env.Stdout, value)
+	return nil
+}
+
+// runList handles `vault list [query] [--format F] [--reveal]`
+func runList(env *Env, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	reveal := fs.Bool("reveal", false, "Include passwords in json/yaml output")
+	query := fs.String("query", "", "Only list entries matching a search query")
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+
+	// Search words are a query too; with both, entries must match both
+	search := strings.Join(fs.Args(), " ")
+	switch {
+	case *query != "" && search != "":
+		search = "(" + *query + ") (" + search + ")"
+	case *query != "":
+		search = *query
+	}
+	if _, err := models.ParseQuery(search); err != nil {
+		return usagef("%v", err)
+	}
+
+	entries, folders, err := env.searchEntries(src, search)
+	if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		views := make([]EntryView, 0, len(entries))
+		for i := range entries {
+			views = append(views, newEntryView(&entries[i], folders, *reveal))
+		}
+		return env.emit(views)
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tTITLE\tFOLDER\tUSERNAME\tURL\tTAGS")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.ID, entry.Schema().Kind, entry.Title, models.FolderPath(folders, entry.FolderID), entry.Username, entry.URL, strings.Join(entry.Tags, ","))
+	}
+	return w.Flush()
+}
+
+// runShow handles `vault show <title|id> [--format F] [--reveal]`
+func runShow(env *Env, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	reveal := fs.Bool("reveal", false, "Include the password")
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault show [flags] <title|id>")
+	}
+
+	entry, folders, err := env.lookupEntry(src, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	view := newEntryView(entry, folders, *reveal)
+	if env.structured() {
+		return env.emit(view)
+	}
+
+	password := "********"
+	if view.Password != nil {
+		password = *view.Password
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "id:\t%s\n", view.ID)
+	fmt.Fprintf(w, "kind:\t%s\n", view.Kind)
+	fmt.Fprintf(w, "title:\t%s\n", view.Title)
+	fmt.Fprintf(w, "folder:\t%s\n", view.Folder)
+	// Other kinds only show the built-in fields their schema has
+	schema := entry.Schema()
+	for _, line := range []struct{ key, value string }{
+		{models.KeyUsername, view.Username},
+		{models.KeyPassword, password},
+		{models.KeyURL, view.URL},
+		{models.KeyNotes, view.Notes},
+	} {
+		if _, ok := schema.Field(line.key); ok {
+			fmt.Fprintf(w, "%s:\t%s\n", line.key, line.value)
+		}
+	}
+	fmt.Fprintf(w, "tags:\t%s\n", strings.Join(view.Tags, ", "))
+	if view.Favorite {
+		fmt.Fprintf(w, "favorite:\tyes\n")
+	}
+	for _, detail := range view.Details {
+		value := "********"
+		if detail.Value != nil {
+			value = *detail.Value
+		}
+		if field, ok := schema.Field(detail.Name); ok && field.Long {
+			value = strings.ReplaceAll(strings.TrimSpace(value), "\n", "\n\t")
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", detail.Name, value)
+	}
+	for _, field := range view.CustomFields {
+		value := "********"
+		if field.Value != nil {
+			value = *field.Value
+		}
+		fmt.Fprintf(w, "%s:\t%s\n", field.Name, value)
+	}
+	fmt.Fprintf(w, "created:\t%s\n", view.CreatedAt.Format("2006-01-02 15:04"))
+	fmt.Fprintf(w, "updated:\t%s\n", view.UpdatedAt.Format("2006-01-02 15:04"))
+	return w.Flush()
+}
+
+// entryFlags are the per-field flags shared by add and edit
+type entryFlags struct {
+	title, username, password, url, notes *string
+	otp                                   *string
+	tags                                  *string
+	folder                                *string
+	fields                                *[]models.CustomField
+	details                               map[string]string
+	favorite                              *bool
+	generate                              *bool
+	generator                             *generatorFlags
+}
+
+func addEntryFlags(fs *flag.FlagSet) *entryFlags {
+	return &entryFlags{
+		title:     fs.String("title", "", "Entry title"),
+		username:  fs.String("username", "", "Username"),
+		password:  fs.String("password", "", "Password (prompted for if omitted; visible to other local users when passed here)"),
+		url:       fs.String("url", "", "URL"),
+		notes:     fs.String("notes", "", "Notes"),
+		otp:       fs.String("otp", "", "One-time password key: otpauth:// URI or base32 seed"),
+		tags:      fs.String("tags", "", "Tags, separated by commas or spaces"),
+		folder:    fs.String("folder", "", "Folder `PATH` such as infra/aws, created if missing; empty for the top level"),
+		fields:    customFieldFlag(fs),
+		details:   detailFlag(fs),
+		favorite:  fs.Bool("favorite", false, "Rank the entry first in searches; --favorite=false unmarks it"),
+		generate:  fs.Bool("generate", false, "Generate a random password"),
+		generator: addGeneratorFlags(fs),
+	}
+}
+
+// runAdd handles `vault add --title T [flags]`
+func runAdd(env *Env, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	flags := addEntryFlags(fs)
+	kindName := fs.String("kind", "login", "Kind of entry: login, card, identity, note, ssh_key, wifi or database")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: vault add --title TITLE [flags]")
+	}
+
+	title := strings.TrimSpace(*flags.title)
+	if title == "" {
+		return usagef("--title is required")
+	}
+	kind, err := models.ParseKind(*kindName)
+	if err != nil {
+		return usagef("%v", err)
+	}
+	schema := models.SchemaFor(kind)
+	if err := checkKindFlags(fs, flags, schema); err != nil {
+		return err
+	}
+
+	// A running agent adds the entry without the master password
+	client := env.agentClient(src)
+	var u *unlocked
+	if client != nil {
+		defer client.Close()
+	} else {
+		var err error
+		if u, err = env.unlock(src, true); err != nil {
+			return err
+		}
+		defer u.close()
+	}
+
+	// Only logins insist on a password; other kinds take one if given
+	var password string
+	if field, ok := schema.Field(models.KeyPassword); ok && (field.Required || isFlagSet(fs, "password") || *flags.generate) {
+		if password, err = env.entryPassword(flags, isFlagSet(fs, "password") || !field.Required); err != nil {
+			return err
+		}
+	}
+
+	otpKey, err := otpFlag(flags)
+	if err != nil {
+		return err
+	}
+
+	fields := models.EntryFields{
+		Kind:     kind,
+		Title:    title,
+		Username: strings.TrimSpace(*flags.username),
+		Password: password,
+		URL:      models.NormalizeURL(strings.TrimSpace(*flags.url)),
+		Notes:    strings.TrimSpace(*flags.notes),
+		OTP:      otpKey,
+		Tags:     models.ParseTags(*flags.tags),
+		Favorite: *flags.favorite,
+	}
+	for key, value := range flags.details {
+		fields.SetValue(key, value)
+	}
+	if err := fields.ApplySchema(); err != nil {
+		return usagef("%v", err)
+	}
+	for _, field := range *flags.fields {
+		fields.SetCustomField(field)
+	}
+	entry := models.NewPasswordEntry(fields)
+
+	var folders []models.Folder
+	if client != nil {
+		if entry, folders, err = client.Add(entry, *flags.folder); err != nil {
+			return err
+		}
+	} else {
+		if entry.FolderID, err = u.vault.MkdirAll(*flags.folder); err != nil {
+			return err
+		}
+		u.vault.AddEntry(entry)
+		if err := u.save(env); err != nil {
+			return err
+		}
+		folders = u.vault.Folders
+	}
+
+	if env.structured() {
+		return env.emit(newEntryView(entry, folders, false))
+	}
+	fmt.Fprintln(env.Stdout, entry.ID)
+	return nil
+}
+
+// runEdit handles `vault edit <title|id> [flags]`; only the given flags change
+func runEdit(env *Env, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	flags := addEntryFlags(fs)
+	removeFields := stringsFlag(fs, "remove-field", "Remove the custom field `NAME` (repeatable)")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault edit [flags] <title|id>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	entry, err := u.vault.FindEntry(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := checkKindFlags(fs, flags, entry.Schema()); err != nil {
+		return err
+	}
+
+	fields := entry.Fields()
+	if isFlagSet(fs, "title") {
+		fields.Title = strings.TrimSpace(*flags.title)
+		if fields.Title == "" {
+			return usagef("--title cannot be empty")
+		}
+	}
+	if isFlagSet(fs, "username") {
+		fields.Username = strings.TrimSpace(*flags.username)
+	}
+	if isFlagSet(fs, "url") {
+		fields.URL = models.NormalizeURL(strings.TrimSpace(*flags.url))
+	}
+	if isFlagSet(fs, "notes") {
+		fields.Notes = strings.TrimSpace(*flags.notes)
+	}
+	if isFlagSet(fs, "tags") {
+		fields.Tags = models.ParseTags(*flags.tags)
+	}
+	if isFlagSet(fs, "favorite") {
+		fields.Favorite = *flags.favorite
+	}
+	if isFlagSet(fs, "folder") {
+		if fields.FolderID, err = u.vault.MkdirAll(*flags.folder); err != nil {
+			return err
+		}
+	}
+	if isFlagSet(fs, "otp") {
+		if fields.OTP, err = otpFlag(flags); err != nil {
+			return err
+		}
+	}
+	if isFlagSet(fs, "password") || *flags.generate {
+		if fields.Password, err = env.entryPassword(flags, true); err != nil {
+			return err
+		}
+	}
+	for _, name := range *removeFields {
+		if !fields.RemoveCustomField(name) {
+			return fmt.Errorf("%s has no field %q", entry.Title, name)
+		}
+	}
+	for _, field := range *flags.fields {
+		fields.SetCustomField(field)
+	}
+	for key, value := range flags.details {
+		fields.SetValue(key, value)
+	}
+	if err := fields.ApplySchema(); err != nil {
+		return usagef("%v", err)
+	}
+
+	u.vault.UpdateEntry(entry.ID, fields)
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		updated, _ := u.vault.GetEntry(entry.ID)
+		return env.emit(newEntryView(updated, u.vault.Folders, false))
+	}
+	fmt.Fprintf(env.Stderr, "updated %s\n", fields.Title)
+	return nil
+}
+
+// runRemove handles `vault rm <title|id> [--yes]`, moving the entry to the
+// trash
+func runRemove(env *Env, args []string) error {
+	fs := flag.NewFlagSet("rm", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault rm [--yes] <title|id>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	entry, err := u.vault.FindEntry(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if !*yes {
+		fmt.Fprintf(env.Stderr, "Move %s (%s) to the trash? [y/N] ", u.vault.EntryPath(entry), entry.ID)
+		answer, err := env.readLine()
+		if err != nil {
+			return err
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("delete cancelled")
+		}
+	}
+
+	deleted := newEntryView(entry, u.vault.Folders, false)
+	u.vault.DeleteEntry(entry.ID)
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(deleted)
+	}
+	fmt.Fprintf(env.Stderr, "moved %s to the trash; vault trash restore brings it back\n", deleted.Title)
+	return nil
+}
+
+// entryPassword returns the password for add/edit: generated, from the
+// --password flag, or prompted for
+func (env *Env) entryPassword(flags *entryFlags, fromFlag bool) (string, error) {
+	if *flags.generate {
+		password, _, err := flags.generator.generate()
+		return password, err
+	}
+
+	password := *flags.password
+	if !fromFlag {
+		var err error
+		if password, err = env.promptPassword("Entry password: "); err != nil {
+			return "", err
+		}
+	}
+
+	password = strings.TrimSpace(password)
+	if password == "" {
+		return "", errors.New("password cannot be empty")
+	}
+	return password, nil
+}
+
+// customFieldFlag registers the repeatable --field NAME[:TYPE]=VALUE flag
+func customFieldFlag(fs *flag.FlagSet) *[]models.CustomField {
+	var fields []models.CustomField
+	fs.Func("field", "Set a custom field, `NAME[:TYPE]=VALUE`; TYPE is text, hidden, url, email or date (repeatable)", func(value string) error {
+		field, err := parseFieldFlag(value)
+		if err != nil {
+			return err
+		}
+		fields = append(fields, field)
+		return nil
+	})
+	return &fields
+}
+
+// parseFieldFlag reads NAME[:TYPE]=VALUE. A suffix that is not a field type
+// is kept as part of the name.
+func parseFieldFlag(value string) (models.CustomField, error) {
+	name, fieldValue, ok := strings.Cut(value, "=")
+	if !ok {
+		return models.CustomField{}, fmt.Errorf("want NAME[:TYPE]=VALUE, got %q", value)
+	}
+
+	fieldType := models.FieldText
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		if t, err := models.ParseFieldType(name[i+1:]); err == nil {
+			name, fieldType = name[:i], t
+		}
+	}
+
+	field := models.NewCustomField(name, fieldValue, fieldType).Normalize()
+	if err := field.Validate(); err != nil {
+		return models.CustomField{}, err
+	}
+	return field, nil
+}
+
+// detailFlag registers the repeatable --detail KEY=VALUE flag for the
+// fields of an entry's kind
+func detailFlag(fs *flag.FlagSet) map[string]string {
+	details := make(map[string]string)
+	fs.Func("detail", "Set a field of the entry's kind, `KEY=VALUE`, such as number=4111... for a card; empty VALUE clears it (repeatable)", func(value string) error {
+		key, detail, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return fmt.Errorf("want KEY=VALUE, got %q", value)
+		}
+		details[strings.ToLower(strings.TrimSpace(key))] = detail
+		return nil
+	})
+	return details
+}
+
+// checkKindFlags rejects flags for fields the entry's kind does not have
+func checkKindFlags(fs *flag.FlagSet, flags *entryFlags, schema models.Schema) error {
+	for _, key := range []string{models.KeyUsername, models.KeyPassword, models.KeyURL, models.KeyNotes} {
+		if _, ok := schema.Field(key); !ok && isFlagSet(fs, key) {
+			return usagef("%s entries have no %s; see --detail", schema.Label, key)
+		}
+	}
+	if _, ok := schema.Field(models.KeyPassword); !ok && *flags.generate {
+		return usagef("%s entries have no password to generate", schema.Label)
+	}
+	if !schema.OTP && isFlagSet(fs, "otp") {
+		return usagef("%s entries have no one-time password", schema.Label)
+	}
+
+	var keys []string
+	for _, field := range schema.Fields {
+		if !field.Builtin() {
+			keys = append(keys, field.Key)
+		}
+	}
+	for key := range flags.details {
+		if !slices.Contains(keys, key) {
+			if len(keys) == 0 {
+				return usagef("%s entries have no details", schema.Label)
+			}
+			return usagef("%s entries have no detail %q (want one of %s)", schema.Label, key, strings.Join(keys, ", "))
+		}
+	}
+	return nil
+}
+
+// stringsFlag registers a repeatable string flag
+func stringsFlag(fs *flag.FlagSet, name, usage string) *[]string {
+	var values []string
+	fs.Func(name, usage, func(value string) error {
+		values = append(values, value)
+		return nil
+	})
+	return &values
+}
+
+// otpFlag validates the --otp value; empty removes the key
+func otpFlag(flags *entryFlags) (string, error) {
+	value := strings.TrimSpace(*flags.otp)
+	if value == "" {
+		return "", nil
+	}
+	if _, err := otp.Parse(value); err != nil {
+		return "", usagef("--otp: %v", err)
+	}
+	return value, nil
+}
+
+// isFlagSet reports whether the named flag was given on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// reorderArgs moves flags ahead of positional arguments so both
+// `vault get NAME --field url` and `vault get --field url NAME` work
+func reorderArgs(fs *flag.FlagSet, args []string) []string {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+
+		flags = append(flags, arg)
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		// Non-boolean flags take the next argument as their value
+		if f := fs.Lookup(name); f != nil && i+1 < len(args) {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	return append(flags, append([]string{"--"}, positional...)...)
+}
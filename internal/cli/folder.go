@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	"vault/internal/models"
+)
+
+// runFolder handles `vault folder list|add|rename|rm`
+func runFolder(env *Env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: vault folder list|add|rename|rm [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runFolderList(env, args[1:])
+	case "add":
+		return runFolderAdd(env, args[1:])
+	case "rename":
+		return runFolderRename(env, args[1:])
+	case "rm":
+		return runFolderRemove(env, args[1:])
+	default:
+		return usagef("unknown folder command %q", args[0])
+	}
+}
+
+// folderView is the structured output schema for a folder
+type folderView struct {
+	ID      string `json:"id" yaml:"id"`
+	Path    string `json:"path" yaml:"path"`
+	Entries int    `json:"entries" yaml:"entries"`
+}
+
+func newFolderView(vault *models.Vault, id string) folderView {
+	return folderView{ID: id, Path: vault.FolderPath(id), Entries: len(vault.EntriesIn(id))}
+}
+
+// folderTree lists the folders depth first, each before its subfolders
+func folderTree(vault *models.Vault, parentID string) []folderView {
+	var views []folderView
+	for _, folder := range vault.Subfolders(parentID) {
+		views = append(views, newFolderView(vault, folder.ID))
+		views = append(views, folderTree(vault, folder.ID)...)
+	}
+	return views
+}
+
+func runFolderList(env *Env, args []string) error {
+	fs := flag.NewFlagSet("folder list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	u, err := env.unlock(src, false)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	views := folderTree(u.vault, "")
+	if env.structured() {
+		if views == nil {
+			views = []folderView{}
+		}
+		return env.emit(views)
+	}
+
+	if len(views) == 0 {
+		fmt.Fprintln(env.Stdout, "no folders")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPATH\tENTRIES")
+	for _, view := range views {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", view.ID, view.Path, view.Entries)
+	}
+	return w.Flush()
+}
+
+func runFolderAdd(env *Env, args []string) error {
+	fs := flag.NewFlagSet("folder add", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault folder add <path>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	if id, err := u.vault.ResolveFolder(fs.Arg(0)); err == nil {
+		if id == "" {
+			return usagef("folder path cannot be empty")
+		}
+		return fmt.Errorf("%w: %s", models.ErrFolderExists, fs.Arg(0))
+	}
+	id, err := u.vault.MkdirAll(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	view := newFolderView(u.vault, id)
+	if env.structured() {
+		return env.emit(view)
+	}
+	fmt.Fprintf(env.Stderr, "created %s\n", view.Path)
+	return nil
+}
+
+func runFolderRename(env *Env, args []string) error {
+	fs := flag.NewFlagSet("folder rename", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 2 {
+		return usagef("usage: vault folder rename <path> <new name>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	id, err := u.resolveFolder(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := u.vault.RenameFolder(id, fs.Arg(1)); err != nil {
+		return err
+	}
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	view := newFolderView(u.vault, id)
+	if env.structured() {
+		return env.emit(view)
+	}
+	fmt.Fprintf(env.Stderr, "renamed to %s\n", view.Path)
+	return nil
+}
+
+func runFolderRemove(env *Env, args []string) error {
+	fs := flag.NewFlagSet("folder rm", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault folder rm <path>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	id, err := u.resolveFolder(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	removed := newFolderView(u.vault, id)
+	if err := u.vault.DeleteFolder(id); err != nil {
+		return err
+	}
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(removed)
+	}
+	fmt.Fprintf(env.Stderr, "deleted %s (its contents moved up a level)\n", removed.Path)
+	return nil
+}
+
+// resolveFolder finds an existing folder; unlike ResolveFolder it refuses
+// the top level, which cannot be renamed or deleted
+func (u *unlocked) resolveFolder(path string) (string, error) {
+	id, err := u.vault.ResolveFolder(path)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", usagef("folder path cannot be empty")
+	}
+	return id, nil
+}
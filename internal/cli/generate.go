@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"math"
+
+	"vault/internal/generator"
+)
+
+// generateView is the structured output of `vault generate`
+type generateView struct {
+	Password    string `json:"password" yaml:"password"`
+	EntropyBits int    `json:"entropy_bits" yaml:"entropy_bits"`
+}
+
+// generatorFlags are the password generator options shared by generate, add
+// and edit
+type generatorFlags struct {
+	length           *int
+	noLower          *bool
+	noUpper          *bool
+	noDigits         *bool
+	noSymbols        *bool
+	excludeAmbiguous *bool
+	passphrase       *bool
+	words            *int
+	separator        *string
+	capitalize       *bool
+	number           *bool
+}
+
+func addGeneratorFlags(fs *flag.FlagSet) *generatorFlags {
+	return &generatorFlags{
+		length:           fs.Int("length", generator.DefaultLength, fmt.Sprintf("Length of a generated password (%d-%d)", generator.MinLength, generator.MaxLength)),
+		noLower:          fs.Bool("no-lower", false, "Leave lower-case letters out of a generated password"),
+		noUpper:          fs.Bool("no-upper", false, "Leave upper-case letters out of a generated password"),
+		noDigits:         fs.Bool("no-digits", false, "Leave digits out of a generated password"),
+		noSymbols:        fs.Bool("no-symbols", false, "Leave symbols out of a generated password"),
+		excludeAmbiguous: fs.Bool("exclude-ambiguous", false, "Leave out look-alike characters such as 0, O, 1 and l"),
+		passphrase:       fs.Bool("passphrase", false, "Generate a passphrase of random words instead"),
+		words:            fs.Int("words", generator.DefaultWords, fmt.Sprintf("Words in a generated passphrase (%d-%d)", generator.MinWords, generator.MaxWords)),
+		separator:        fs.String("separator", generator.DefaultSeparator, "Separator between passphrase words"),
+		capitalize:       fs.Bool("capitalize", false, "Capitalize passphrase words"),
+		number:           fs.Bool("number", false, "Add a digit to one passphrase word"),
+	}
+}
+
+// generate produces a password or passphrase as the flags ask, with its
+// strength in bits
+func (f *generatorFlags) generate() (string, float64, error) {
+	if *f.passphrase {
+		opts := generator.PassphraseOptions{
+			Words:      *f.words,
+			Separator:  *f.separator,
+			Capitalize: *f.capitalize,
+			Number:     *f.number,
+		}
+		if err := opts.Validate(); err != nil {
+			return "", 0, usagef("%v", err)
+		}
+		passphrase, err := generator.Passphrase(opts)
+		return passphrase, opts.Entropy(), err
+	}
+
+	opts := generator.Options{
+		Length:           *f.length,
+		Lower:            !*f.noLower,
+		Upper:            !*f.noUpper,
+		Digits:           !*f.noDigits,
+		Symbols:          !*f.noSymbols,
+		ExcludeAmbiguous: *f.excludeAmbiguous,
+		RequireEach:      true,
+	}
+	if err := opts.Validate(); err != nil {
+		return "", 0, usagef("%v", err)
+	}
+	password, err := generator.Password(opts)
+	return password, opts.Entropy(), err
+}
+
+// runGenerate handles `vault generate [flags]`; it needs no vault
+func runGenerate(env *Env, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	flags := addGeneratorFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 0 {
+		return usagef("usage: vault generate [flags]")
+	}
+
+	password, entropy, err := flags.generate()
+	if err != nil {
+		return err
+	}
+	if env.structured() {
+		return env.emit(generateView{Password: password, EntropyBits: int(math.Floor(entropy))})
+	}
+	fmt.Fprintln(env.Stdout, password)
+	return nil
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"vault/internal/models"
+)
+
+// runHistory handles `vault history list|restore`
+func runHistory(env *Env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: vault history list|restore [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runHistoryList(env, args[1:])
+	case "restore":
+		return runHistoryRestore(env, args[1:])
+	default:
+		return usagef("unknown history command %q", args[0])
+	}
+}
+
+// historyView is the structured output schema for a previous value. Value
+// is nil for a concealed value unless --reveal is given.
+type historyView struct {
+	Number    int       `json:"number" yaml:"number"`
+	Field     string    `json:"field" yaml:"field"`
+	Custom    bool      `json:"custom,omitempty" yaml:"custom,omitempty"`
+	Value     *string   `json:"value" yaml:"value"`
+	ChangedAt time.Time `json:"changed_at" yaml:"changed_at"`
+}
+
+func newHistoryView(number int, item models.HistoryItem, reveal bool) historyView {
+	view := historyView{Number: number, Field: item.Field, Custom: item.Custom, ChangedAt: item.ChangedAt}
+	if !item.Concealed || reveal {
+		value := item.Value
+		view.Value = &value
+	}
+	return view
+}
+
+func runHistoryList(env *Env, args []string) error {
+	fs := flag.NewFlagSet("history list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	reveal := fs.Bool("reveal", false, "Include concealed values such as old passwords")
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault history list [flags] <title|id>")
+	}
+
+	entry, _, err := env.lookupEntry(src, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if env.structured() {
+		views := make([]historyView, 0, len(entry.History))
+		for i, item := range entry.History {
+			views = append(views, newHistoryView(i+1, item, *reveal))
+		}
+		return env.emit(views)
+	}
+
+	if len(entry.History) == 0 {
+		fmt.Fprintf(env.Stdout, "no history for %s\n", entry.Title)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tCHANGED\tFIELD\tVALUE")
+	for i, item := range entry.History {
+		view := newHistoryView(i+1, item, *reveal)
+		value := "********"
+		if view.Value != nil {
+			value = strings.ReplaceAll(strings.TrimSpace(*view.Value), "\n", " ")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", view.Number, item.ChangedAt.Local().Format("2006-01-02 15:04:05"), entry.HistoryLabel(item), value)
+	}
+	return w.Flush()
+}
+
+func runHistoryRestore(env *Env, args []string) error {
+	fs := flag.NewFlagSet("history restore", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 2 {
+		return usagef("usage: vault history restore [flags] <title|id> <number>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	entry, err := u.vault.FindEntry(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	number, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || number < 1 || number > len(entry.History) {
+		return usagef("no history item %q for %s; see vault history list", fs.Arg(1), entry.Title)
+	}
+	item := entry.History[number-1]
+
+	// The value being replaced goes into the history in turn
+	fields := entry.Fields()
+	if err := fields.Restore(item); err != nil {
+		return err
+	}
+	u.vault.UpdateEntry(entry.ID, fields)
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		updated, _ := u.vault.GetEntry(entry.ID)
+		return env.emit(newEntryView(updated, u.vault.Folders, false))
+	}
+	fmt.Fprintf(env.Stderr, "restored %s of %s from %s\n", entry.HistoryLabel(item), fields.Title, item.ChangedAt.Local().Format("2006-01-02 15:04"))
+	return nil
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"time"
+
+	"vault/internal/config"
+	"vault/internal/crypto"
+)
+
+// runKDF handles `vault kdf show|calibrate`
+func runKDF(env *Env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: vault kdf show|calibrate [flags]")
+	}
+
+	switch args[0] {
+	case "show":
+		return runKDFShow(env, args[1:])
+	case "calibrate":
+		return runKDFCalibrate(env, args[1:])
+	default:
+		return usagef("unknown kdf command %q", args[0])
+	}
+}
+
+// kdfView is the structured output of `vault kdf show`
+type kdfView struct {
+	NewVaults crypto.KDFParams `json:"new_vaults" yaml:"new_vaults"`
+	Current   *vaultKDFView    `json:"current" yaml:"current"`
+}
+
+type vaultKDFView struct {
+	Path          string           `json:"path" yaml:"path"`
+	FormatVersion int              `json:"format_version" yaml:"format_version"`
+	KDF           crypto.KDFParams `json:"kdf" yaml:"kdf"`
+	Cipher        string           `json:"cipher" yaml:"cipher"`
+}
+
+func runKDFShow(env *Env, args []string) error {
+	fs := flag.NewFlagSet("kdf show", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	store, cfg, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+
+	view := kdfView{NewVaults: cfg.KDFParams()}
+	if store.VaultExists() {
+		version, header, err := store.ReadHeader()
+		if err != nil {
+			return err
+		}
+		view.Current = &vaultKDFView{
+			Path:          store.GetVaultPath(),
+			FormatVersion: version,
+			KDF:           header.KDF,
+			Cipher:        header.Cipher,
+		}
+	}
+
+	if env.structured() {
+		return env.emit(view)
+	}
+
+	fmt.Fprintf(env.Stdout, "new vaults:    %s\n", view.NewVaults)
+	if view.Current == nil {
+		fmt.Fprintf(env.Stdout, "current vault: none at %s\n", store.GetVaultPath())
+		return nil
+	}
+	fmt.Fprintf(env.Stdout, "current vault: %s, %s (format v%d)\n", view.Current.KDF, view.Current.Cipher, view.Current.FormatVersion)
+	return nil
+}
+
+func runKDFCalibrate(env *Env, args []string) error {
+	fs := flag.NewFlagSet("kdf calibrate", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	target := fs.Duration("target", time.Second, "Target unlock time")
+	maxMemory := fs.Uint("max-memory", 1024, "Maximum memory to use in MiB")
+	parallelism := fs.Uint("parallelism", uint(min(runtime.NumCPU(), 4)), "Number of threads")
+	dryRun := fs.Bool("dry-run", false, "Print the parameters without saving them")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	if *target <= 0 {
+		return usagef("--target must be positive")
+	}
+	if *maxMemory < 8 || *maxMemory > crypto.Argon2MaxMemory/1024 {
+		return usagef("--max-memory must be between 8 and %d MiB", crypto.Argon2MaxMemory/1024)
+	}
+	if *parallelism < 1 || *parallelism > crypto.Argon2MaxParallelism {
+		return usagef("--parallelism must be between 1 and %d", crypto.Argon2MaxParallelism)
+	}
+
+	fmt.Fprintf(env.Stderr, "calibrating argon2id for %s...\n", *target)
+	params, elapsed := crypto.CalibrateArgon2id(*target, uint32(*maxMemory)*1024, uint8(*parallelism))
+
+	path := ""
+	if !*dryRun {
+		store, cfg, err := env.openStorage()
+		if err != nil {
+			return err
+		}
+
+		cfg.KDF = &params
+		path = config.Path(store.GetVaultPath())
+		if err := cfg.Save(path); err != nil {
+			return err
+		}
+	}
+
+	if env.structured() {
+		return env.emit(map[string]any{
+			"kdf":        params,
+			"elapsed_ms": elapsed.Milliseconds(),
+			"saved_to":   path,
+		})
+	}
+
+	fmt.Fprintf(env.Stdout, "selected %s, took %s\n", params, elapsed.Round(time.Millisecond))
+	if path != "" {
+		fmt.Fprintf(env.Stdout, "saved to %s; used for new vaults and password changes\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"vault/internal/models"
+	"vault/internal/otp"
+)
+
+// otpView is the structured output of `vault otp`
+type otpView struct {
+	ID               string  `json:"id" yaml:"id"`
+	Type             string  `json:"type" yaml:"type"`
+	Code             string  `json:"code" yaml:"code"`
+	RemainingSeconds int     `json:"remaining_seconds,omitempty" yaml:"remaining_seconds,omitempty"` // totp
+	Counter          *uint64 `json:"counter,omitempty" yaml:"counter,omitempty"`                     // hotp, of this code
+}
+
+// runOTP handles `vault otp <title|id>`
+func runOTP(env *Env, args []string) error {
+	fs := flag.NewFlagSet("otp", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault otp [flags] <title|id>")
+	}
+
+	entry, _, err := env.useEntry(src, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	key, err := entryOTPKey(entry)
+	if err != nil {
+		return err
+	}
+
+	view := otpView{ID: entry.ID, Type: key.Type}
+	if key.Type == otp.TypeHOTP {
+		var counter uint64
+		if view.Code, counter, err = env.nextHOTP(src, entry.ID); err != nil {
+			return err
+		}
+		view.Counter = &counter
+	} else {
+		now := time.Now()
+		if view.Code, err = key.TOTP(now); err != nil {
+			return err
+		}
+		view.RemainingSeconds = int(key.Remaining(now) / time.Second)
+	}
+
+	if env.structured() {
+		return env.emit(view)
+	}
+	fmt.Fprintln(env.Stdout, view.Code)
+	return nil
+}
+
+// entryOTPKey parses the one-time password key of an entry
+func entryOTPKey(entry *models.PasswordEntry) (*otp.Key, error) {
+	if entry.OTP == "" {
+		return nil, fmt.Errorf("%s has no one-time password", entry.Title)
+	}
+	return otp.Parse(entry.OTP)
+}
+
+// nextHOTP issues the next code of a counter-based key and saves the
+// advanced counter, returning the code and the counter it was made from.
+// It always opens the vault for writing, since the agent only serves reads
+// and additions.
+func (env *Env) nextHOTP(src *passwordSource, id string) (string, uint64, error) {
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return "", 0, err
+	}
+	defer u.close()
+
+	// Re-read under the lock so a concurrent use cannot repeat a code
+	entry, ok := u.vault.GetEntry(id)
+	if !ok {
+		return "", 0, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	key, err := entryOTPKey(entry)
+	if err != nil {
+		return "", 0, err
+	}
+	counter := key.Counter
+	code, err := key.NextHOTP()
+	if err != nil {
+		return "", 0, err
+	}
+
+	u.vault.AdvanceOTP(id, key.URI())
+	if err := u.save(env); err != nil {
+		return "", 0, err
+	}
+	return code, counter, nil
+}
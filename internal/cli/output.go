@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// Output formats accepted by --format
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatYAML  = "yaml"
+)
+
+// Process exit codes. Scripts can rely on these staying stable.
+const (
+	ExitOK            = 0
+	ExitError         = 1 // Any error not listed below
+	ExitUsage         = 2 // Bad command line arguments
+	ExitWrongPassword = 3 // Master password did not decrypt the vault
+	ExitNotFound      = 4 // Vault file, entry or backup does not exist
+	ExitLocked        = 5 // Another process holds the vault lock
+	ExitCorrupt       = 6 // Vault file is damaged or in an unknown format
+	ExitAmbiguous     = 7 // Entry reference matched several entries
+)
+
+// Error codes reported in structured error objects, one per exit code
+var errorCodes = map[int]string{
+	ExitError:         "error",
+	ExitUsage:         "usage",
+	ExitWrongPassword: "wrong_password",
+	ExitNotFound:      "not_found",
+	ExitLocked:        "locked",
+	ExitCorrupt:       "corrupt",
+	ExitAmbiguous:     "ambiguous",
+}
+
+// exitStatus is returned by a command that has already reported its own
+// failure, such as one relayed from a child process
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// exitCode classifies err into one of the documented exit codes
+func exitCode(err error) int {
+	var usage usageError
+	var status exitStatus
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &status):
+		return int(status)
+	case errors.As(err, &usage):
+		return ExitUsage
+	case errors.Is(err, storage.ErrWrongPassword):
+		return ExitWrongPassword
+	case errors.Is(err, ErrNotFound), errors.Is(err, storage.ErrVaultNotFound), errors.Is(err, storage.ErrBackupNotFound),
+		errors.Is(err, models.ErrFolderNotFound):
+		return ExitNotFound
+	case errors.Is(err, storage.ErrLocked):
+		return ExitLocked
+	case errors.Is(err, storage.ErrCorrupt), errors.Is(err, storage.ErrInvalidFormat),
+		errors.Is(err, storage.ErrUnsupportedVersion), errors.Is(err, storage.ErrUnsupportedCipher):
+		return ExitCorrupt
+	case errors.Is(err, ErrAmbiguous):
+		return ExitAmbiguous
+	default:
+		return ExitError
+	}
+}
+
+// errorObject is the structured form of an error, written to stderr in the
+// machine-readable format requested
+type errorObject struct {
+	Error struct {
+		Code     string `json:"code" yaml:"code"`
+		Message  string `json:"message" yaml:"message"`
+		ExitCode int    `json:"exit_code" yaml:"exit_code"`
+	} `json:"error" yaml:"error"`
+}
+
+// writeError reports err on stderr as text, or as an error object in the
+// structured format requested: one line of JSON, or a YAML document
+func (env *Env) writeError(command string, err error) int {
+	code := exitCode(err)
+
+	var status exitStatus
+	if errors.As(err, &status) {
+		return code
+	}
+
+	if env.structured() {
+		var obj errorObject
+		obj.Error.Code = errorCodes[code]
+		obj.Error.Message = err.Error()
+		obj.Error.ExitCode = code
+		if env.format == FormatYAML {
+			encode(env.Stderr, FormatYAML, obj)
+			return code
+		}
+		data, _ := json.Marshal(obj)
+		fmt.Fprintln(env.Stderr, string(data))
+		return code
+	}
+
+	fmt.Fprintf(env.Stderr, "vault %s: %v\n", command, err)
+	return code
+}
+
+// addFormatFlag registers --format on fs, storing the choice on env
+func (env *Env) addFormatFlag(fs *flag.FlagSet) {
+	fs.Func("format", "Output format: table, json or yaml", func(value string) error {
+		switch value {
+		case FormatTable, FormatJSON, FormatYAML:
+			env.format = value
+			return nil
+		default:
+			return fmt.Errorf("unknown format %q", value)
+		}
+	})
+}
+
+// structured reports whether output should be JSON or YAML
+func (env *Env) structured() bool {
+	return env.format == FormatJSON || env.format == FormatYAML
+}
+
+// emit writes v to stdout in the requested structured format
+func (env *Env) emit(v any) error {
+	return encode(env.Stdout, env.format, v)
+}
+
+func encode(w io.Writer, format string, v any) error {
+	switch format {
+	case FormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep URLs and otpauth URIs readable
+		return enc.Encode(v)
+	}
+}
+
+// EntryView is the documented machine-readable schema for a password entry.
+// Password is null unless it was explicitly revealed.
+type EntryView struct {
+	ID           string      `json:"id" yaml:"id"`
+	Kind         string      `json:"kind" yaml:"kind"`
+	Title        string      `json:"title" yaml:"title"`
+	Username     string      `json:"username" yaml:"username"`
+	Password     *string     `json:"password" yaml:"password"`
+	URL          string      `json:"url" yaml:"url"`
+	Notes        string      `json:"notes" yaml:"notes"`
+	Details      []FieldView `json:"details,omitempty" yaml:"details,omitempty"` // Kind-specific fields, named by key
+	OTP          *string     `json:"otp,omitempty" yaml:"otp,omitempty"`         // Only with --reveal
+	CustomFields []FieldView `json:"custom_fields,omitempty" yaml:"custom_fields,omitempty"`
+	Tags         []string    `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Folder       string      `json:"folder,omitempty" yaml:"folder,omitempty"` // Path, empty at the top level
+	Favorite     bool        `json:"favorite,omitempty" yaml:"favorite,omitempty"`
+	CreatedAt    time.Time   `json:"created_at" yaml:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at" yaml:"updated_at"`
+}
+
+// FieldView is a custom field or kind-specific detail in the output schema.
+// Value is null for a concealed field unless it was explicitly revealed.
+type FieldView struct {
+	Name      string  `json:"name" yaml:"name"`
+	Type      string  `json:"type" yaml:"type"`
+	Value     *string `json:"value" yaml:"value"`
+	Concealed bool    `json:"concealed" yaml:"concealed"`
+}
+
+// newEntryView converts an entry to its output schema, naming its folder
+// from the vault's folders
+func newEntryView(entry *models.PasswordEntry, folders []models.Folder, reveal bool) EntryView {
+	view := EntryView{
+		ID:        entry.ID,
+		Kind:      string(entry.Schema().Kind),
+		Title:     entry.Title,
+		Username:  entry.Username,
+		URL:       entry.URL,
+		Notes:     entry.Notes,
+		Tags:      entry.Tags,
+		Folder:    models.FolderPath(folders, entry.FolderID),
+		Favorite:  entry.Favorite,
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+	}
+	for _, field := range entry.Schema().Fields {
+		if field.Builtin() || entry.Details[field.Key] == "" {
+			continue
+		}
+		fieldView := FieldView{Name: field.Key, Type: string(field.Type), Concealed: field.Concealed()}
+		if reveal || !field.Concealed() {
+			value := entry.Details[field.Key]
+			fieldView.Value = &value
+		}
+		view.Details = append(view.Details, fieldView)
+	}
+	for _, field := range entry.CustomFields {
+		fieldView := FieldView{Name: field.Name, Type: string(field.Kind()), Concealed: field.Concealed}
+		if reveal || !field.Concealed {
+			value := field.Value
+			fieldView.Value = &value
+		}
+		view.CustomFields = append(view.CustomFields, fieldView)
+	}
+	if reveal {
+		password := entry.Password
+		view.Password = &password
+		if entry.OTP != "" {
+			otpKey := entry.OTP
+			view.OTP = &otpKey
+		}
+	}
+	return view
+}
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"vault/internal/storage"
+)
+
+// runPasswd handles `vault passwd`, re-keying the vault and its backups under
+// a new password
+func runPasswd(env *Env, args []string) error {
+	fs := flag.NewFlagSet("passwd", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	store, cfg, err := env.openStorage()
+	if err != nil {
+		return err
+	}
+	if !store.VaultExists() {
+		return fmt.Errorf("%w: %s", storage.ErrVaultNotFound, store.GetVaultPath())
+	}
+
+	current, err := env.promptPassword("Current master password: ")
+	if err != nil {
+		return err
+	}
+	password, err := env.promptPassword("New master password: ")
+	if err != nil {
+		return err
+	}
+	confirm, err := env.promptPassword("Confirm new master password: ")
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(password) < 8:
+		return errors.New("password must be at least 8 characters")
+	case password != confirm:
+		return errors.New("passwords do not match")
+	case password == current:
+		return errors.New("new password must differ from the current one")
+	}
+
+	params := cfg.KDFParams()
+	_, session, err := store.ChangeMasterPassword(current, password, params)
+	if err != nil {
+		return err
+	}
+
+	// Backups under the old password would still open with it
+	rekeyed, deleted, err := store.RekeyBackups(current, session)
+	session.Wipe()
+	if err != nil {
+		fmt.Fprintf(env.Stderr, "warning: %v; backups in %s may still open with the old password\n", err, store.BackupDir())
+	}
+
+	// The agent's key no longer opens the vault
+	env.lockAgent()
+
+	if env.structured() {
+		return env.emit(map[string]any{
+			"changed":         true,
+			"kdf":             params,
+			"backups_rekeyed": rekeyed,
+			"backups_deleted": deleted,
+		})
+	}
+	fmt.Fprintf(env.Stdout, "master password changed (%s)\n", params)
+	fmt.Fprintf(env.Stdout, "backups: %d re-encrypted, %d deleted\n", rekeyed, deleted)
+	return nil
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// promptPassword asks for a password on the terminal without echoing it.
+// When stdin is not a terminal the next line of input is used instead.
+func (env *Env) promptPassword(prompt string) (string, error) {
+	if f, ok := env.Stdin.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		fmt.Fprint(env.Stderr, prompt)
+		password, err := term.ReadPassword(f.Fd())
+		fmt.Fprintln(env.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return strings.TrimSpace(string(password)), nil
+	}
+
+	line, err := env.readLine()
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readLine reads a single line from stdin, buffering across calls
+func (env *Env) readLine() (string, error) {
+	if env.stdinReader == nil {
+		env.stdinReader = bufio.NewReader(env.Stdin)
+	}
+
+	line, err := env.stdinReader.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"vault/internal/models"
+)
+
+// runTrash handles `vault trash list|restore|empty`
+func runTrash(env *Env, args []string) error {
+	if len(args) == 0 {
+		return usagef("usage: vault trash list|restore|empty [flags]")
+	}
+
+	switch args[0] {
+	case "list":
+		return runTrashList(env, args[1:])
+	case "restore":
+		return runTrashRestore(env, args[1:])
+	case "empty":
+		return runTrashEmpty(env, args[1:])
+	default:
+		return usagef("unknown trash command %q", args[0])
+	}
+}
+
+// trashView is the structured output schema for a deleted entry. PurgesAt
+// is omitted when the trash keeps entries until it is emptied.
+type trashView struct {
+	EntryView `yaml:",inline"`
+	DeletedAt time.Time  `json:"deleted_at" yaml:"deleted_at"`
+	PurgesAt  *time.Time `json:"purges_at,omitempty" yaml:"purges_at,omitempty"`
+}
+
+func newTrashView(trashed *models.TrashedEntry, folders []models.Folder, retention time.Duration, reveal bool) trashView {
+	view := trashView{
+		EntryView: newEntryView(&trashed.PasswordEntry, folders, reveal),
+		DeletedAt: trashed.DeletedAt,
+	}
+	if purgesAt := trashed.PurgesAt(retention); !purgesAt.IsZero() {
+		view.PurgesAt = &purgesAt
+	}
+	return view
+}
+
+func runTrashList(env *Env, args []string) error {
+	fs := flag.NewFlagSet("trash list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	reveal := fs.Bool("reveal", false, "Include passwords")
+	if err := fs.Parse(args); err != nil {
+		return usagef("%v", err)
+	}
+
+	u, err := env.unlock(src, false)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	retention := u.store.TrashRetention()
+	views := make([]trashView, 0, len(u.vault.Trash))
+	for i := len(u.vault.Trash) - 1; i >= 0; i-- {
+		views = append(views, newTrashView(&u.vault.Trash[i], u.vault.Folders, retention, *reveal))
+	}
+	if env.structured() {
+		return env.emit(views)
+	}
+
+	if len(views) == 0 {
+		fmt.Fprintln(env.Stdout, "trash is empty")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(env.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tKIND\tTITLE\tFOLDER\tDELETED\tPURGED")
+	for _, view := range views {
+		purged := "when emptied"
+		if view.PurgesAt != nil {
+			purged = view.PurgesAt.Local().Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", view.ID, view.Kind, view.Title, view.Folder, view.DeletedAt.Local().Format("2006-01-02 15:04"), purged)
+	}
+	return w.Flush()
+}
+
+func runTrashRestore(env *Env, args []string) error {
+	fs := flag.NewFlagSet("trash restore", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() != 1 {
+		return usagef("usage: vault trash restore [flags] <title|id>")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	trashed, err := u.vault.FindTrashed(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	entry, err := u.vault.RestoreEntry(trashed.ID)
+	if err != nil {
+		return err
+	}
+	view := newEntryView(entry, u.vault.Folders, false)
+	path := u.vault.EntryPath(entry)
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(view)
+	}
+	fmt.Fprintf(env.Stderr, "restored %s\n", path)
+	return nil
+}
+
+func runTrashEmpty(env *Env, args []string) error {
+	fs := flag.NewFlagSet("trash empty", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	src := addPasswordFlags(fs)
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	env.addFormatFlag(fs)
+	if err := fs.Parse(reorderArgs(fs, args)); err != nil {
+		return usagef("%v", err)
+	}
+	if fs.NArg() > 1 {
+		return usagef("usage: vault trash empty [--yes] [<title|id>]")
+	}
+
+	u, err := env.unlock(src, true)
+	if err != nil {
+		return err
+	}
+	defer u.close()
+
+	// With an entry given, only that one is purged
+	what := fmt.Sprintf("all %d entries in the trash", len(u.vault.Trash))
+	if len(u.vault.Trash) == 1 {
+		what = "the entry in the trash"
+	}
+	var trashed *models.TrashedEntry
+	if fs.NArg() == 1 {
+		if trashed, err = u.vault.FindTrashed(fs.Arg(0)); err != nil {
+			return err
+		}
+		what = fmt.Sprintf("%s (%s)", trashed.Title, trashed.ID)
+	} else if len(u.vault.Trash) == 0 {
+		fmt.Fprintln(env.Stderr, "trash is already empty")
+		return nil
+	}
+
+	if !*yes {
+		fmt.Fprintf(env.Stderr, "Delete %s for good? [y/N] ", what)
+		answer, err := env.readLine()
+		if err != nil {
+			return err
+		}
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return errors.New("purge cancelled")
+		}
+	}
+
+	purged := 1
+	if trashed != nil {
+		u.vault.PurgeEntry(trashed.ID)
+	} else {
+		purged = u.vault.EmptyTrash()
+	}
+	if err := u.save(env); err != nil {
+		return err
+	}
+
+	if env.structured() {
+		return env.emit(map[string]int{"purged": purged})
+	}
+	fmt.Fprintf(env.Stderr, "deleted %s for good\n", what)
+	return nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"vault/internal/crypto"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+// PasswordEnv is the environment variable holding the master password
+const PasswordEnv = "VAULT_PASSWORD"
+
+var (
+	ErrNotFound  = models.ErrNotFound
+	ErrAmbiguous = models.ErrAmbiguous
+)
+
+// passwordSource records where the master password should come from
+type passwordSource struct {
+	file  string
+	stdin bool
+}
+
+// addPasswordFlags registers the master password source flags on fs
+func addPasswordFlags(fs *flag.FlagSet) *passwordSource {
+	src := &passwordSource{}
+	fs.StringVar(&src.file, "password-file", "", "Read the master password from the first line of `FILE`")
+	fs.BoolVar(&src.stdin, "password-stdin", false, "Read the master password from the first line of stdin")
+	return src
+}
+
+// masterPassword resolves the master password from, in order: --password-file,
+// --password-stdin, $VAULT_PASSWORD, or an interactive prompt
+func (env *Env) masterPassword(src *passwordSource) (string, error) {
+	switch {
+	case src.file != "":
+		data, err := os.ReadFile(src.file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read password file: %w", err)
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimSpace(line), nil
+
+	case src.stdin:
+		line, err := env.readLine()
+		if err != nil {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimSpace(line), nil
+
+	case os.Getenv(PasswordEnv) != "":
+		return os.Getenv(PasswordEnv), nil
+
+	default:
+		return env.promptPassword("Master password: ")
+	}
+}
+
+// unlocked is an open vault for the duration of one command
+type unlocked struct {
+	store   *storage.Storage
+	vault   *models.Vault
+	session *crypto.Session
+}
+
+// unlock opens the vault. With write set, the vault lock is held until
+// close so the whole read-modify-write cycle is protected.
+func (env *Env) unlock(src *passwordSource, write bool) (*unlocked, error) {
+	store, _, err := env.openStorage()
+	if err != nil {
+		return nil, err
+	}
+	if !store.VaultExists() {
+		return nil, fmt.Errorf("%w: %s (run vault to create one)", storage.ErrVaultNotFound, store.GetVaultPath())
+	}
+
+	password, err := env.masterPassword(src)
+	if err != nil {
+		return nil, err
+	}
+	if password == "" {
+		return nil, errors.New("master password cannot be empty")
+	}
+
+	if write {
+		if err := store.Lock(); err != nil {
+			return nil, err
+		}
+	}
+
+	vault, session, err := store.LoadVault(password)
+	if err != nil {
+		store.Unlock()
+		return nil, err
+	}
+
+	return &unlocked{store: store, vault: vault, session: session}, nil
+}
+
+// save writes the vault, reporting a merge with external changes on stderr
+func (u *unlocked) save(env *Env) error {
+	err := u.store.SaveVault(u.vault, u.session)
+
+	var conflictErr *storage.ConflictError
+	var syncErr *storage.SyncError
+	if errors.As(err, &conflictErr) || errors.As(err, &syncErr) {
+		fmt.Fprintf(env.Stderr, "warning: %v\n", err)
+		return nil
+	}
+	return err
+}
+
+// close wipes the session key and releases the vault lock
+func (u *unlocked) close() {
+	u.session.Wipe()
+	u.store.Unlock()
+}
@@ -0,0 +1,168 @@
+package clipboard
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"vault/internal/daemon"
+)
+
+// ClearCommand is the hidden subcommand that runs the clearing helper
+const ClearCommand = "clipboard-clear"
+
+// Write puts text on the system clipboard
+func Write(text string) error {
+	// Try the go-clipboard library first (most reliable)
+	if err := clipboard.WriteAll(text); err == nil {
+		return nil
+	}
+
+	// Fallback to system commands
+	return writeSystem(text)
+}
+
+// Read returns the current contents of the system clipboard
+func Read() (string, error) {
+	if text, err := clipboard.ReadAll(); err == nil {
+		return text, nil
+	}
+	return readSystem()
+}
+
+// Fingerprint identifies a copied value without revealing it
+func Fingerprint(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// ClearIfUnchanged empties the clipboard if it still holds the value with the
+// given fingerprint, so anything copied since is left alone. It reports
+// whether the clipboard was cleared.
+func ClearIfUnchanged(fingerprint string) (bool, error) {
+	current, err := Read()
+	if err != nil {
+		return false, err
+	}
+	if subtle.ConstantTimeCompare([]byte(Fingerprint(current)), []byte(fingerprint)) != 1 {
+		return false, nil
+	}
+	return true, Write("")
+}
+
+// StartClearer launches a detached copy of this program that clears the
+// clipboard after the delay if it still holds text. It keeps running if the
+// caller exits first; kill the returned process to cancel it. Only the
+// fingerprint is handed over, on stdin rather than the command line.
+func StartClearer(text string, after time.Duration) (*os.Process, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate vault executable: %w", err)
+	}
+
+	cmd := exec.Command(exe, ClearCommand, "--after", after.String())
+	cmd.Stdin = strings.NewReader(Fingerprint(text) + "\n")
+	daemon.Detach(cmd)
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start clipboard helper: %w", err)
+	}
+
+	// Reap the helper if we are still around when it finishes
+	go cmd.Wait()
+	return cmd.Process, nil
+}
+
+func writeSystem(text string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbcopy")
+	case "linux":
+		// Try different clipboard utilities in order of preference
+		if _, err := exec.LookPath("wl-copy"); err == nil {
+			// Wayland
+			cmd = exec.Command("wl-copy")
+		} else if _, err := exec.LookPath("xclip"); err == nil {
+			// X11 with xclip
+			cmd = exec.Command("xclip", "-selection", "clipboard")
+		} else if _, err := exec.LookPath("xsel"); err == nil {
+			// X11 with xsel
+			cmd = exec.Command("xsel", "--clipboard", "--input")
+		} else {
+			return fmt.Errorf("no clipboard utility found (install xclip, xsel, or wl-clipboard)")
+		}
+	case "windows":
+		cmd = exec.Command("clip")
+	default:
+		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	// Set up the command to read from stdin
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start clipboard command: %w", err)
+	}
+
+	// Write the text to the clipboard command
+	if _, err := stdin.Write([]byte(text)); err != nil {
+		stdin.Close()
+		cmd.Wait()
+		return fmt.Errorf("failed to write to clipboard: %w", err)
+	}
+
+	// Close stdin and wait for the command to complete
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("clipboard command failed: %w", err)
+	}
+
+	return nil
+}
+
+func readSystem() (string, error) {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbpaste")
+	case "linux":
+		// Same utilities and order as writeSystem
+		if _, err := exec.LookPath("wl-paste"); err == nil {
+			cmd = exec.Command("wl-paste", "--no-newline")
+		} else if _, err := exec.LookPath("xclip"); err == nil {
+			cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
+		} else if _, err := exec.LookPath("xsel"); err == nil {
+			cmd = exec.Command("xsel", "--clipboard", "--output")
+		} else {
+			return "", fmt.Errorf("no clipboard utility found (install xclip, xsel, or wl-clipboard)")
+		}
+	case "windows":
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard -Raw")
+	default:
+		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("clipboard command failed: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		out = bytes.TrimSuffix(out, []byte("\r\n"))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"vault/internal/agent"
+	"vault/internal/crypto"
+	"vault/internal/models"
+	"vault/internal/storage"
+)
+
+const (
+	DefaultConfigFile = "config.json"
+	ConfigPermissions = 0600 // Owner read/write only
+
+	DefaultIdleLock       = 5 * time.Minute  // Interactive session lock after inactivity
+	DefaultClipboardClear = 30 * time.Second // Copied secrets are cleared after this
+)
+
+// Config holds user settings stored beside the vault file
+type Config struct {
+	// KDF is used when creating or re-keying a vault; nil means the built-in default
+	KDF *crypto.KDFParams `json:"kdf,omitempty"`
+
+	// Backups controls the encrypted copies kept before each save
+	Backups *BackupConfig `json:"backups,omitempty"`
+
+	// Agent controls the background agent started by `vault agent`
+	Agent *AgentConfig `json:"agent,omitempty"`
+
+	// UI controls the interactive interface
+	UI *UIConfig `json:"ui,omitempty"`
+
+	// Clipboard controls how long copied secrets stay on the clipboard
+	Clipboard *ClipboardConfig `json:"clipboard,omitempty"`
+
+	// History controls the previous values kept on each entry
+	History *HistoryConfig `json:"history,omitempty"`
+
+	// Trash controls how long deleted entries can be restored
+	Trash *TrashConfig `json:"trash,omitempty"`
+}
+
+// BackupConfig is the retention policy for automatic backups
+type BackupConfig struct {
+	Count      int `json:"count"`        // Backups to keep; 0 disables backups
+	MaxAgeDays int `json:"max_age_days"` // 0 keeps backups regardless of age
+}
+
+// AgentConfig holds the agent settings
+type AgentConfig struct {
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes"` // 0 never locks on idle
+}
+
+// UIConfig holds the interactive interface settings
+type UIConfig struct {
+	IdleLockMinutes int `json:"idle_lock_minutes"` // 0 never locks on idle
+}
+
+// ClipboardConfig holds the clipboard settings
+type ClipboardConfig struct {
+	ClearSeconds int `json:"clear_seconds"` // 0 leaves copied secrets in place
+}
+
+// HistoryConfig holds the entry history settings
+type HistoryConfig struct {
+	Depth     int  `json:"depth"`      // Previous values kept per field; 0 keeps none
+	AllFields bool `json:"all_fields"` // Also keep usernames, URLs and other plain fields
+}
+
+// TrashConfig holds the trash settings
+type TrashConfig struct {
+	PurgeDays int `json:"purge_days"` // 0 keeps deleted entries until the trash is emptied
+}
+
+// Path returns the config file location for the given vault file
+func Path(vaultPath string) string {
+	return filepath.Join(filepath.Dir(vaultPath), DefaultConfigFile)
+}
+
+// Load reads the config file, returning defaults if it does not exist
+func Load(path string) (*Config, error) {
+	cfg := &Config{}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Save writes the config file with secure permissions
+func (c *Config) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), ConfigPermissions); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// KDFParams returns the key derivation parameters for new vaults
+func (c *Config) KDFParams() crypto.KDFParams {
+	if c.KDF != nil {
+		return *c.KDF
+	}
+	return crypto.DefaultKDFParams()
+}
+
+// BackupPolicy returns the backup retention policy
+func (c *Config) BackupPolicy() storage.BackupPolicy {
+	if c.Backups == nil {
+		return storage.DefaultBackupPolicy()
+	}
+	return storage.BackupPolicy{
+		Count:  c.Backups.Count,
+		MaxAge: time.Duration(c.Backups.MaxAgeDays) * 24 * time.Hour,
+	}
+}
+
+// AgentIdleTimeout returns how long the agent stays unlocked without use
+func (c *Config) AgentIdleTimeout() time.Duration {
+	if c.Agent == nil {
+		return agent.DefaultIdleTimeout
+	}
+	return time.Duration(c.Agent.IdleTimeoutMinutes) * time.Minute
+}
+
+// IdleLock returns how long the interactive session stays unlocked without
+// a keypress
+func (c *Config) IdleLock() time.Duration {
+	if c.UI == nil {
+		return DefaultIdleLock
+	}
+	return time.Duration(c.UI.IdleLockMinutes) * time.Minute
+}
+
+// ClipboardClear returns how long a copied secret stays on the clipboard
+func (c *Config) ClipboardClear() time.Duration {
+	if c.Clipboard == nil {
+		return DefaultClipboardClear
+	}
+	return time.Duration(c.Clipboard.ClearSeconds) * time.Second
+}
+
+// HistoryPolicy returns which previous values entries keep
+func (c *Config) HistoryPolicy() models.HistoryPolicy {
+	if c.History == nil {
+		return models.DefaultHistoryPolicy()
+	}
+	return models.HistoryPolicy{Depth: c.History.Depth, AllFields: c.History.AllFields}
+}
+
+// TrashRetention returns how long deleted entries stay in the trash
+func (c *Config) TrashRetention() time.Duration {
+	if c.Trash == nil {
+		return storage.DefaultTrashRetention
+	}
+	return time.Duration(c.Trash.PurgeDays) * 24 * time.Hour
+}
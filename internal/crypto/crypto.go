@@ -8,7 +8,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"time"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -19,12 +21,63 @@ const (
 	NonceLength  = 12          // GCM nonce length
 )
 
+// Argon2id defaults for new vaults
+const (
+	Argon2Memory      = 64 * 1024 // Memory in KiB (64 MiB)
+	Argon2Time        = 3         // Number of passes
+	Argon2Parallelism = 4         // Number of lanes
+)
+
+// Largest Argon2id parameters accepted, so a corrupt or hostile header
+// cannot make unlocking allocate or compute without bound
+const (
+	Argon2MaxMemory      = 4 * 1024 * 1024 // Memory in KiB (4 GiB)
+	Argon2MaxTime        = 100             // Number of passes
+	Argon2MaxParallelism = 255             // Number of lanes
+)
+
+// Algorithm identifiers recorded in the vault file header
+const (
+	KDFPBKDF2SHA256 = "pbkdf2-sha256"
+	KDFArgon2id     = "argon2id"
+	CipherAES256GCM = "aes-256-gcm"
+)
+
 var (
 	ErrInvalidKeyLength = errors.New("invalid key length")
 	ErrInvalidNonce     = errors.New("invalid nonce length")
 	ErrDecryption       = errors.New("decryption failed")
+	ErrUnsupportedKDF   = errors.New("unsupported key derivation function")
 )
 
+// KDFParams describes how the encryption key is derived from the master password
+type KDFParams struct {
+	Name string `json:"name" yaml:"name"`
+
+	// PBKDF2
+	Iterations int `json:"iterations,omitempty" yaml:"iterations,omitempty"`
+
+	// Argon2id
+	Memory      uint32 `json:"memory,omitempty" yaml:"memory,omitempty"` // KiB
+	Time        uint32 `json:"time,omitempty" yaml:"time,omitempty"`
+	Parallelism uint8  `json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+}
+
+// DefaultKDFParams returns the key derivation parameters used for new vaults
+func DefaultKDFParams() KDFParams {
+	return KDFParams{
+		Name:        KDFArgon2id,
+		Memory:      Argon2Memory,
+		Time:        Argon2Time,
+		Parallelism: Argon2Parallelism,
+	}
+}
+
+// LegacyKDFParams returns the parameters implied by headerless vault files
+func LegacyKDFParams() KDFParams {
+	return KDFParams{Name: KDFPBKDF2SHA256, Iterations: Iterations}
+}
+
 // GenerateSalt creates a cryptographically secure random salt
 func GenerateSalt() ([]byte, error) {
 	salt := make([]byte, SaltLength)
@@ -39,8 +92,112 @@ func DeriveKey(password string, salt []byte) []byte {
 	return pbkdf2.Key([]byte(password), salt, Iterations, KeyLength, sha256.New)
 }
 
+// DeriveKeyWithParams derives an encryption key using the given KDF parameters
+func DeriveKeyWithParams(password string, salt []byte, params KDFParams) ([]byte, error) {
+	switch params.Name {
+	case KDFPBKDF2SHA256:
+		if params.Iterations <= 0 {
+			return nil, fmt.Errorf("invalid PBKDF2 iteration count: %d", params.Iterations)
+		}
+		return pbkdf2.Key([]byte(password), salt, params.Iterations, KeyLength, sha256.New), nil
+	case KDFArgon2id:
+		if err := params.validateArgon2(); err != nil {
+			return nil, err
+		}
+		return argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, KeyLength), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKDF, params.Name)
+	}
+}
+
+// validateArgon2 rejects parameters argon2 would panic on and those above
+// the Argon2Max limits. It does not judge strength: weak parameters a vault
+// was written with still unlock it.
+func (p KDFParams) validateArgon2() error {
+	if p.Time < 1 || p.Time > Argon2MaxTime {
+		return fmt.Errorf("invalid argon2id time cost: %d", p.Time)
+	}
+	if p.Parallelism < 1 { // A uint8, so never above Argon2MaxParallelism
+		return fmt.Errorf("invalid argon2id parallelism: %d", p.Parallelism)
+	}
+	if p.Memory < 8*uint32(p.Parallelism) || p.Memory > Argon2MaxMemory {
+		return fmt.Errorf("invalid argon2id memory: %d KiB", p.Memory)
+	}
+	return nil
+}
+
+// String returns a short human readable description of the parameters
+func (p KDFParams) String() string {
+	switch p.Name {
+	case KDFPBKDF2SHA256:
+		return fmt.Sprintf("%s (%d iterations)", p.Name, p.Iterations)
+	case KDFArgon2id:
+		return fmt.Sprintf("%s (memory %d MiB, time %d, parallelism %d)", p.Name, p.Memory/1024, p.Time, p.Parallelism)
+	default:
+		return p.Name
+	}
+}
+
+// CalibrateArgon2id picks Argon2id parameters whose derivation takes roughly
+// target on this machine. Memory is doubled first (up to maxMemory KiB) since
+// it is what makes GPU attacks expensive, then the time cost is scaled to
+// fill the remaining budget. It returns the parameters and the measured time.
+func CalibrateArgon2id(target time.Duration, maxMemory uint32, parallelism uint8) (KDFParams, time.Duration) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	maxMemory = min(maxMemory, Argon2MaxMemory)
+
+	params := KDFParams{
+		Name:        KDFArgon2id,
+		Memory:      Argon2Memory,
+		Time:        1,
+		Parallelism: parallelism,
+	}
+	if params.Memory > maxMemory {
+		params.Memory = maxMemory
+	}
+	if floor := 8 * uint32(parallelism); params.Memory < floor {
+		params.Memory = floor
+	}
+
+	elapsed := measureKDF(params)
+
+	// Grow memory while a single pass stays comfortably under the target
+	for elapsed*2 <= target && params.Memory*2 <= maxMemory {
+		params.Memory *= 2
+		elapsed = measureKDF(params)
+	}
+
+	// Spend what is left of the budget on additional passes
+	if elapsed > 0 && elapsed < target {
+		params.Time = uint32(min(target/elapsed, Argon2MaxTime))
+		if params.Time > 1 {
+			elapsed = measureKDF(params)
+		}
+	}
+
+	return params, elapsed
+}
+
+// measureKDF times a single key derivation with throwaway inputs
+func measureKDF(params KDFParams) time.Duration {
+	salt := make([]byte, SaltLength)
+	start := time.Now()
+	key, _ := DeriveKeyWithParams("calibration", salt, params)
+	elapsed := time.Since(start)
+	SecureWipe(key)
+	return elapsed
+}
+
 // Encrypt encrypts plaintext using AES-256-GCM
 func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
+	return EncryptWithAAD(plaintext, key, nil)
+}
+
+// EncryptWithAAD encrypts plaintext using AES-256-GCM, authenticating
+// additionalData alongside it without encrypting it
+func EncryptWithAAD(plaintext []byte, key []byte, additionalData []byte) ([]byte, error) {
 	if len(key) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
@@ -64,12 +221,18 @@ func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
 	}
 
 	// Encrypt and authenticate the data
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, additionalData)
 	return ciphertext, nil
 }
 
 // Decrypt decrypts ciphertext using AES-256-GCM
 func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	return DecryptWithAAD(ciphertext, key, nil)
+}
+
+// DecryptWithAAD decrypts ciphertext using AES-256-GCM, verifying that it
+// was sealed with the same additionalData
+func DecryptWithAAD(ciphertext []byte, key []byte, additionalData []byte) ([]byte, error) {
 	if len(key) != KeyLength {
 		return nil, ErrInvalidKeyLength
 	}
@@ -95,7 +258,7 @@ func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
 	encrypted := ciphertext[NonceLength:]
 
 	// Decrypt and verify the data
-	plaintext, err := gcm.Open(nil, nonce, encrypted, nil)
+	plaintext, err := gcm.Open(nil, nonce, encrypted, additionalData)
 	if err != nil {
 		return nil, ErrDecryption
 	}
@@ -0,0 +1,61 @@
+package crypto
+
+import (
+	"errors"
+)
+
+var ErrSessionClosed = errors.New("session is locked")
+
+// Session holds the key derived at unlock so the master password itself is
+// never kept, and later saves do not have to re-run the KDF
+type Session struct {
+	key  []byte
+	salt []byte
+	kdf  KDFParams
+}
+
+// NewSession derives the vault key from password once and keeps only the key
+func NewSession(password string, salt []byte, params KDFParams) (*Session, error) {
+	key, err := DeriveKeyWithParams(password, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Session{
+		key:  key,
+		salt: append([]byte(nil), salt...),
+		kdf:  params,
+	}, nil
+}
+
+// Key returns the derived key; callers must not retain or modify it
+func (s *Session) Key() ([]byte, error) {
+	if s == nil || s.key == nil {
+		return nil, ErrSessionClosed
+	}
+	return s.key, nil
+}
+
+// Salt returns the salt the key was derived with
+func (s *Session) Salt() []byte {
+	return s.salt
+}
+
+// KDFParams returns the parameters the key was derived with
+func (s *Session) KDFParams() KDFParams {
+	return s.kdf
+}
+
+// Valid reports whether the session still holds a key
+func (s *Session) Valid() bool {
+	return s != nil && s.key != nil
+}
+
+// Wipe zeroes the key; the session cannot be used afterwards
+func (s *Session) Wipe() {
+	if s == nil {
+		return
+	}
+	SecureWipe(s.key)
+	s.key = nil
+}
@@ -0,0 +1,15 @@
+package daemon
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const detachedProcess = 0x00000008 // DETACHED_PROCESS
+
+// Detach makes cmd run without a console so it outlives the terminal
+func Detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+	}
+}
@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Character classes a password can draw from
+const (
+	LowerChars  = "abcdefghijklmnopqrstuvwxyz"
+	UpperChars  = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	DigitChars  = "0123456789"
+	SymbolChars = "!@#$%^&*()-_=+"
+
+	// AmbiguousChars look alike in many fonts
+	AmbiguousChars = "0Oo1lI"
+)
+
+// Password length bounds
+const (
+	MinLength     = 8
+	MaxLength     = 128
+	DefaultLength = 16
+)
+
+var ErrNoClasses = errors.New("at least one character class is required")
+
+// Options describe a generated password
+type Options struct {
+	Length           int
+	Lower            bool
+	Upper            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+	RequireEach      bool // Every enabled class appears at least once
+}
+
+// DefaultOptions returns a 16 character password using every class
+func DefaultOptions() Options {
+	return Options{
+		Length:      DefaultLength,
+		Lower:       true,
+		Upper:       true,
+		Digits:      true,
+		Symbols:     true,
+		RequireEach: true,
+	}
+}
+
+// classes returns the enabled character sets, without ambiguous characters
+// if requested
+func (o Options) classes() []string {
+	var classes []string
+	for _, class := range []struct {
+		enabled bool
+		chars   string
+	}{
+		{o.Lower, LowerChars},
+		{o.Upper, UpperChars},
+		{o.Digits, DigitChars},
+		{o.Symbols, SymbolChars},
+	} {
+		if !class.enabled {
+			continue
+		}
+		chars := class.chars
+		if o.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(AmbiguousChars, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+		classes = append(classes, chars)
+	}
+	return classes
+}
+
+// Validate checks the options can produce a password
+func (o Options) Validate() error {
+	if o.Length < MinLength || o.Length > MaxLength {
+		return fmt.Errorf("length must be between %d and %d", MinLength, MaxLength)
+	}
+	if len(o.classes()) == 0 {
+		return ErrNoClasses
+	}
+	return nil
+}
+
+// Entropy returns the strength of a password made with these options in
+// bits. Requiring every class costs slightly less than this.
+func (o Options) Entropy() float64 {
+	charset := strings.Join(o.classes(), "")
+	if charset == "" {
+		return 0
+	}
+	return float64(o.Length) * math.Log2(float64(len(charset)))
+}
+
+// Password generates a random password. Every character is drawn uniformly
+// from the enabled classes; when each class is required, candidates missing
+// one are thrown away rather than patched, so the result stays uniform over
+// all passwords that qualify.
+func Password(opts Options) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	classes := opts.classes()
+	charset := strings.Join(classes, "")
+	password := make([]byte, opts.Length)
+
+	for {
+		for i := range password {
+			n, err := randIndex(len(charset))
+			if err != nil {
+				return "", err
+			}
+			password[i] = charset[n]
+		}
+		if !opts.RequireEach || hasEach(password, classes) {
+			return string(password), nil
+		}
+	}
+}
+
+// hasEach reports whether password contains a character of every class
+func hasEach(password []byte, classes []string) bool {
+	for _, class := range classes {
+		if !strings.ContainsAny(string(password), class) {
+			return false
+		}
+	}
+	return true
+}
+
+// randIndex returns a uniform random number in [0, n). Values from the top
+// of the range that would favour small results are rejected instead of
+// being reduced modulo n.
+func randIndex(n int) (int, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid range %d", n)
+	}
+	bound := uint32(n)
+	limit := math.MaxUint32 - math.MaxUint32%bound
+
+	var buf [4]byte
+	for {
+		if _, err := rand.Read(buf[:]); err != nil {
+			return 0, fmt.Errorf("failed to read random bytes: %w", err)
+		}
+		if v := binary.BigEndian.Uint32(buf[:]); v < limit {
+			return int(v % bound), nil
+		}
+	}
+}
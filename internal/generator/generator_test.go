@@ -0,0 +1,111 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tries is how many passwords each test generates, enough that a class
+// left out at random would show
+const tries = 200
+
+func TestPasswordRequiresEachClass(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Length = MinLength
+	classes := opts.classes()
+	for range tries {
+		password, err := Password(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, class := range classes {
+			if !strings.ContainsAny(password, class) {
+				t.Fatalf("%q has none of %q", password, class)
+			}
+		}
+	}
+}
+
+func TestPasswordExcludesAmbiguous(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Length = MaxLength
+	opts.ExcludeAmbiguous = true
+	for range tries {
+		password, err := Password(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i := strings.IndexAny(password, AmbiguousChars); i >= 0 {
+			t.Fatalf("%q has ambiguous %q", password, password[i])
+		}
+	}
+}
+
+func TestPasswordLength(t *testing.T) {
+	for _, length := range []int{MinLength, DefaultLength, MaxLength} {
+		opts := DefaultOptions()
+		opts.Length = length
+		password, err := Password(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != length {
+			t.Errorf("%q is %d characters, want %d", password, len(password), length)
+		}
+	}
+}
+
+func TestPasswordImpossible(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"too short", Options{Length: MinLength - 1, Lower: true}},
+		{"too long", Options{Length: MaxLength + 1, Lower: true}},
+	}
+	for _, tt := range tests {
+		if password, err := Password(tt.opts); err == nil {
+			t.Errorf("%s: got %q, want an error", tt.name, password)
+		}
+	}
+	if password, err := Password(Options{Length: DefaultLength, RequireEach: true}); !errors.Is(err, ErrNoClasses) {
+		t.Errorf("no classes: got %q, %v, want %v", password, err, ErrNoClasses)
+	}
+}
+
+func TestPassphraseWords(t *testing.T) {
+	words := make(map[string]bool)
+	for _, word := range Wordlist() {
+		words[word] = true
+	}
+
+	for _, count := range []int{MinWords, DefaultWords, MaxWords} {
+		opts := DefaultPassphraseOptions()
+		opts.Words = count
+		opts.Separator = " " // Some words have dashes
+		passphrase, err := Passphrase(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Split(passphrase, opts.Separator)
+		if len(got) != count {
+			t.Errorf("%q has %d words, want %d", passphrase, len(got), count)
+		}
+		for _, word := range got {
+			if !words[word] {
+				t.Errorf("%q has %q, not from the word list", passphrase, word)
+			}
+		}
+	}
+}
+
+func TestPassphraseImpossible(t *testing.T) {
+	for _, count := range []int{0, MinWords - 1, MaxWords + 1} {
+		opts := DefaultPassphraseOptions()
+		opts.Words = count
+		if passphrase, err := Passphrase(opts); err == nil {
+			t.Errorf("%d words: got %q, want an error", count, passphrase)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Passphrase length bounds
+const (
+	MinWords     = 3
+	MaxWords     = 20
+	DefaultWords = 6
+
+	DefaultSeparator = "-"
+)
+
+// effWordlist is the EFF large wordlist for dice-generated passphrases, one
+// word per line in dice order. It is published by the Electronic Frontier
+// Foundation under CC BY 3.0:
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed eff_large_wordlist.txt
+var effWordlist string
+
+// Wordlist returns the embedded 7776 word list
+var Wordlist = sync.OnceValue(func() []string {
+	return strings.Fields(effWordlist)
+})
+
+// PassphraseOptions describe a generated passphrase
+type PassphraseOptions struct {
+	Words      int
+	Separator  string
+	Capitalize bool // Upper-case the first letter of every word
+	Number     bool // Append a random digit to one word
+}
+
+// DefaultPassphraseOptions returns six lower-case words joined by dashes
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		Words:     DefaultWords,
+		Separator: DefaultSeparator,
+	}
+}
+
+// Validate checks the options can produce a passphrase
+func (o PassphraseOptions) Validate() error {
+	if o.Words < MinWords || o.Words > MaxWords {
+		return fmt.Errorf("words must be between %d and %d", MinWords, MaxWords)
+	}
+	return nil
+}
+
+// Entropy returns the strength of a passphrase made with these options in
+// bits, assuming the word list is known to an attacker
+func (o PassphraseOptions) Entropy() float64 {
+	bits := float64(o.Words) * math.Log2(float64(len(Wordlist())))
+	if o.Number {
+		bits += math.Log2(float64(10 * o.Words))
+	}
+	return bits
+}
+
+// Passphrase picks words uniformly from the embedded word list
+func Passphrase(opts PassphraseOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	list := Wordlist()
+	words := make([]string, opts.Words)
+	for i := range words {
+		n, err := randIndex(len(list))
+		if err != nil {
+			return "", err
+		}
+		words[i] = list[n]
+		if opts.Capitalize {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+	}
+
+	if opts.Number {
+		word, err := randIndex(len(words))
+		if err != nil {
+			return "", err
+		}
+		digit, err := randIndex(10)
+		if err != nil {
+			return "", err
+		}
+		words[word] += strconv.Itoa(digit)
+	}
+
+	return strings.Join(words, opts.Separator), nil
+}
@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"testing"
+
+	"vault/internal/models"
+	"vault/internal/models/modelstest"
+)
+
+// BenchmarkSearch ranks a large vault against a query, search texts
+// already built
+func BenchmarkSearch(b *testing.B) {
+	vault := modelstest.BenchVault()
+	query, err := models.ParseQuery("github adm")
+	if err != nil {
+		b.Fatal(err)
+	}
+	vault.Search(query, nil)
+	b.ResetTimer()
+	for range b.N {
+		vault.Search(query, nil)
+	}
+}
+
+// BenchmarkEditEntry edits an entry of a large vault and records the change
+// for undo
+func BenchmarkEditEntry(b *testing.B) {
+	vault := modelstest.BenchVault()
+	entry := vault.Entries[len(vault.Entries)/2]
+	b.ResetTimer()
+	for i := range b.N {
+		fields := entry.Fields()
+		fields.Password = string(rune('a' + i%26))
+		before := vault.Snapshot()
+		vault.UpdateEntry(entry.ID, fields)
+		vault.ChangeSince(before)
+	}
+}
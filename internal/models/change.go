@@ -0,0 +1,220 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+var ErrChangedSince = errors.New("was changed since")
+
+// Snapshot is a copy of what the vault held at one point, to find out later
+// what an operation changed
+type Snapshot struct {
+	entries []PasswordEntry
+	folders []Folder
+	trash   []TrashedEntry
+}
+
+// Change is what an operation did to the vault: the entries, folders and
+// deleted entries it added, altered or removed, as they were before and
+// after. It can be undone and redone without touching anything else.
+type Change struct {
+	entries []itemChange[PasswordEntry]
+	folders []itemChange[Folder]
+	trash   []itemChange[TrashedEntry]
+}
+
+// itemChange is one item before and after a change; nil where it was
+// missing
+type itemChange[T any] struct {
+	id            string
+	before, after *T
+}
+
+// Snapshot copies the vault's contents. Entries are replaced rather than
+// modified in place, so sharing their fields with the vault is safe.
+func (v *Vault) Snapshot() Snapshot {
+	return Snapshot{
+		entries: append([]PasswordEntry(nil), v.Entries...),
+		folders: append([]Folder(nil), v.Folders...),
+		trash:   append([]TrashedEntry(nil), v.Trash...),
+	}
+}
+
+// ChangeSince returns what was changed since the snapshot was taken
+func (v *Vault) ChangeSince(before Snapshot) Change {
+	return Change{
+		entries: diffItems(before.entries, v.Entries, entryID, sameEntry),
+		folders: diffItems(before.folders, v.Folders, folderID, sameFolder),
+		trash:   diffItems(before.trash, v.Trash, trashedID, sameTrashed),
+	}
+}
+
+// Empty reports whether the change left the vault as it was
+func (c Change) Empty() bool {
+	return len(c.entries) == 0 && len(c.folders) == 0 && len(c.trash) == 0
+}
+
+// Undo puts back what the change replaced. It fails without changing
+// anything if any item it touched has been changed again since.
+func (v *Vault) Undo(c Change) error {
+	return v.apply(c, true)
+}
+
+// Redo makes the change again after it was undone, failing like Undo if
+// anything it touched has changed in between
+func (v *Vault) Redo(c Change) error {
+	return v.apply(c, false)
+}
+
+func (v *Vault) apply(c Change, undo bool) error {
+	if err := checkItems(v.Entries, c.entries, undo, entryID, sameEntry); err != nil {
+		return err
+	}
+	if err := checkItems(v.Folders, c.folders, undo, folderID, sameFolder); err != nil {
+		return err
+	}
+	if err := checkItems(v.Trash, c.trash, undo, trashedID, sameTrashed); err != nil {
+		return err
+	}
+
+	v.SetEntries(applyItems(v.Entries, c.entries, undo, entryID))
+	v.Folders = applyItems(v.Folders, c.folders, undo, folderID)
+	v.Trash = applyItems(v.Trash, c.trash, undo, trashedID)
+	v.RepairFolders()
+	return nil
+}
+
+func entryID(e *PasswordEntry) string  { return e.ID }
+func folderID(f *Folder) string        { return f.ID }
+func trashedID(t *TrashedEntry) string { return t.ID }
+
+// sameEntry reports whether two versions of an entry hold the same, with
+// times equal whatever their location. It avoids reflection, which is slow
+// enough to notice across a large vault on every change, so it must compare
+// every field of an entry.
+func sameEntry(a, b *PasswordEntry) bool {
+	return a.ID == b.ID && a.Kind == b.Kind && a.Title == b.Title &&
+		a.Username == b.Username && a.Password == b.Password && a.URL == b.URL &&
+		a.Notes == b.Notes && maps.Equal(a.Details, b.Details) && a.OTP == b.OTP &&
+		slices.Equal(a.CustomFields, b.CustomFields) && slices.Equal(a.Tags, b.Tags) &&
+		a.FolderID == b.FolderID && a.Favorite == b.Favorite && slices.EqualFunc(a.History, b.History, sameHistoryItem) &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+// sameFolder reports whether two versions of a folder hold the same, like
+// sameEntry
+func sameFolder(a, b *Folder) bool {
+	return a.ID == b.ID && a.Name == b.Name && a.ParentID == b.ParentID &&
+		a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
+// sameTrashed reports whether two versions of a deleted entry hold the same,
+// like sameEntry
+func sameTrashed(a, b *TrashedEntry) bool {
+	return sameEntry(&a.PasswordEntry, &b.PasswordEntry) && a.DeletedAt.Equal(b.DeletedAt)
+}
+
+// sameHistoryItem reports whether two history items hold the same, with
+// times equal whatever their location
+func sameHistoryItem(a, b HistoryItem) bool {
+	return a.Field == b.Field && a.Custom == b.Custom && a.Value == b.Value &&
+		a.Concealed == b.Concealed && a.ChangedAt.Equal(b.ChangedAt)
+}
+
+// diffItems lists the items added, altered or removed between two versions
+// of a list keyed by ID
+func diffItems[T any](before, after []T, id func(*T) string, equal func(a, b *T) bool) []itemChange[T] {
+	old := make(map[string]*T, len(before))
+	for i := range before {
+		old[id(&before[i])] = &before[i]
+	}
+
+	var changes []itemChange[T]
+	for i := range after {
+		item := &after[i]
+		previous, existed := old[id(item)]
+		delete(old, id(item))
+		if !existed || !equal(previous, item) {
+			updated := *item
+			changes = append(changes, itemChange[T]{id: id(item), before: previous, after: &updated})
+		}
+	}
+	for i := range before {
+		if previous, removed := old[id(&before[i])]; removed {
+			changes = append(changes, itemChange[T]{id: id(previous), before: previous})
+		}
+	}
+	return changes
+}
+
+// checkItems makes sure every changed item is still as the change left it,
+// or for a redo as it found it, comparing them as diffItems does
+func checkItems[T any](items []T, changes []itemChange[T], undo bool, id func(*T) string, equal func(a, b *T) bool) error {
+	current := make(map[string]*T, len(items))
+	for i := range items {
+		current[id(&items[i])] = &items[i]
+	}
+	for _, change := range changes {
+		expected := change.after
+		if !undo {
+			expected = change.before
+		}
+		item, ok := current[change.id]
+		if ok != (expected != nil) || (ok && !equal(item, expected)) {
+			return fmt.Errorf("%s %w", describeItem(item, expected), ErrChangedSince)
+		}
+	}
+	return nil
+}
+
+// applyItems sets every changed item to its version before or after the
+// change, removing those that did not exist then
+func applyItems[T any](items []T, changes []itemChange[T], undo bool, id func(*T) string) []T {
+	for _, change := range changes {
+		version := change.before
+		if !undo {
+			version = change.after
+		}
+
+		i := -1
+		for j := range items {
+			if id(&items[j]) == change.id {
+				i = j
+				break
+			}
+		}
+		switch {
+		case i >= 0 && version != nil:
+			items[i] = *version
+		case i >= 0:
+			items = append(items[:i], items[i+1:]...)
+		case version != nil:
+			items = append(items, *version)
+		}
+	}
+	return items
+}
+
+// describeItem names an entry or folder for an error message
+func describeItem[T any](items ...*T) string {
+	for _, item := range items {
+		switch item := any(item).(type) {
+		case *PasswordEntry:
+			if item != nil {
+				return item.Title
+			}
+		case *TrashedEntry:
+			if item != nil {
+				return item.Title + " (in the trash)"
+			}
+		case *Folder:
+			if item != nil {
+				return "folder " + item.Name
+			}
+		}
+	}
+	return "an item"
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// differing returns a copy of v with the field at index set to a value
+// other than its zero value
+func differing[T any](t *testing.T, v T, index int) T {
+	t.Helper()
+	field := reflect.ValueOf(&v).Elem().Field(index)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString("changed")
+	case reflect.Bool:
+		field.SetBool(true)
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		m.SetMapIndex(reflect.Zero(field.Type().Key()), reflect.Zero(field.Type().Elem()))
+		field.Set(m)
+	case reflect.Slice:
+		field.Set(reflect.MakeSlice(field.Type(), 1, 1))
+	default:
+		if field.Type() != reflect.TypeOf(time.Time{}) {
+			t.Fatalf("no differing value for field %s of type %s", reflect.TypeOf(v).Field(index).Name, field.Type())
+		}
+		field.Set(reflect.ValueOf(time.Unix(1, 0)))
+	}
+	return v
+}
+
+func TestSameEntryComparesEveryField(t *testing.T) {
+	typ := reflect.TypeOf(PasswordEntry{})
+	for i := range typ.NumField() {
+		t.Run(typ.Field(i).Name, func(t *testing.T) {
+			a := PasswordEntry{}
+			b := differing(t, a, i)
+			if sameEntry(&a, &b) || sameEntry(&b, &a) {
+				t.Errorf("entries differing in %s compare the same", typ.Field(i).Name)
+			}
+		})
+	}
+}
+
+func TestSameFolderComparesEveryField(t *testing.T) {
+	typ := reflect.TypeOf(Folder{})
+	for i := range typ.NumField() {
+		t.Run(typ.Field(i).Name, func(t *testing.T) {
+			a := Folder{}
+			b := differing(t, a, i)
+			if sameFolder(&a, &b) || sameFolder(&b, &a) {
+				t.Errorf("folders differing in %s compare the same", typ.Field(i).Name)
+			}
+		})
+	}
+}
+
+func TestSameHistoryItemComparesEveryField(t *testing.T) {
+	typ := reflect.TypeOf(HistoryItem{})
+	for i := range typ.NumField() {
+		t.Run(typ.Field(i).Name, func(t *testing.T) {
+			a := HistoryItem{}
+			b := differing(t, a, i)
+			if sameHistoryItem(a, b) || sameHistoryItem(b, a) {
+				t.Errorf("history items differing in %s compare the same", typ.Field(i).Name)
+			}
+		})
+	}
+}
+
+func TestSameEntryTimesInAnyLocation(t *testing.T) {
+	now := time.Now()
+	a := PasswordEntry{
+		ID:        "a",
+		CreatedAt: now,
+		UpdatedAt: now,
+		History:   []HistoryItem{{Field: KeyTitle, Value: "old", ChangedAt: now}},
+	}
+	b := a
+	b.CreatedAt = now.UTC()
+	b.UpdatedAt = now.Round(0) // Without the monotonic reading
+	b.History = []HistoryItem{{Field: KeyTitle, Value: "old", ChangedAt: now.In(time.FixedZone("east", 3600))}}
+	if !sameEntry(&a, &b) {
+		t.Error("entries with equal times in other locations compare different")
+	}
+}
+
+func TestUndoTimesInAnyLocation(t *testing.T) {
+	var v Vault
+	kept := NewPasswordEntry(EntryFields{Title: "github", Password: "p"})
+	deleted := NewPasswordEntry(EntryFields{Title: "gitlab", Password: "p"})
+	v.AddEntry(kept)
+	v.AddEntry(deleted)
+	folder, err := v.CreateFolder("", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := v.Snapshot()
+	fields := kept.Fields()
+	fields.Title = "github edited"
+	v.UpdateEntry(kept.ID, fields)
+	if err := v.RenameFolder(folder.ID, "personal"); err != nil {
+		t.Fatal(err)
+	}
+	v.DeleteEntry(deleted.ID)
+	change := v.ChangeSince(before)
+
+	// Reloading the vault keeps each time but not its location
+	data, err := json.Marshal(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Vault
+	if err := json.Unmarshal(data, &reloaded); err != nil {
+		t.Fatal(err)
+	}
+	if err := reloaded.Undo(change); err != nil {
+		t.Fatalf("undo after reloading: %v", err)
+	}
+	if entry, ok := reloaded.GetEntry(kept.ID); !ok || entry.Title != "github" {
+		t.Errorf("entry %+v, want its title put back", entry)
+	}
+	if _, ok := reloaded.GetEntry(deleted.ID); !ok {
+		t.Error("deleted entry not put back")
+	}
+	if got, _ := reloaded.GetFolder(folder.ID); got == nil || got.Name != "work" {
+		t.Errorf("folder %+v, want its name put back", got)
+	}
+}
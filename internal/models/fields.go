@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// FieldType says how a custom field's value is entered, shown and checked
+type FieldType string
+
+const (
+	FieldText   FieldType = "text"
+	FieldHidden FieldType = "hidden" // A secret such as a PIN or API key
+	FieldURL    FieldType = "url"
+	FieldEmail  FieldType = "email"
+	FieldDate   FieldType = "date" // YYYY-MM-DD
+)
+
+// DateLayout is the format of date field values
+const DateLayout = "2006-01-02"
+
+// FieldTypes lists the field types in the order the form cycles through them
+var FieldTypes = []FieldType{FieldText, FieldHidden, FieldURL, FieldEmail, FieldDate}
+
+var ErrInvalidField = errors.New("invalid custom field")
+
+// CustomField is a named value on an entry beyond the built-in fields
+type CustomField struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	Type      FieldType `json:"type,omitempty"`      // Empty means text
+	Concealed bool      `json:"concealed,omitempty"` // Masked until revealed
+}
+
+// ParseFieldType accepts a field type name, case-insensitively
+func ParseFieldType(name string) (FieldType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return FieldText, nil
+	}
+	for _, t := range FieldTypes {
+		if string(t) == name {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown type %q", ErrInvalidField, name)
+}
+
+// NewCustomField creates a field of the given type; hidden fields start out
+// concealed
+func NewCustomField(name, value string, fieldType FieldType) CustomField {
+	return CustomField{
+		Name:      name,
+		Value:     value,
+		Type:      fieldType,
+		Concealed: fieldType == FieldHidden,
+	}
+}
+
+// Kind returns the field type, defaulting to text
+func (f CustomField) Kind() FieldType {
+	if f.Type == "" {
+		return FieldText
+	}
+	return f.Type
+}
+
+// Normalize trims the field and tidies its value for the type
+func (f CustomField) Normalize() CustomField {
+	f.Name = strings.TrimSpace(f.Name)
+	if f.Kind() != FieldHidden {
+		f.Value = strings.TrimSpace(f.Value)
+	}
+	if f.Kind() == FieldURL {
+		f.Value = NormalizeURL(f.Value)
+	}
+	return f
+}
+
+// Validate checks the field has a name and a value fitting its type
+func (f CustomField) Validate() error {
+	if strings.TrimSpace(f.Name) == "" {
+		return fmt.Errorf("%w: name required", ErrInvalidField)
+	}
+	if f.Value == "" {
+		return nil
+	}
+
+	if err := checkType(f.Kind(), f.Value); err != nil {
+		return fmt.Errorf("%w: %s %v", ErrInvalidField, f.Name, err)
+	}
+	return nil
+}
+
+// checkType validates a non-empty value for a field type
+func checkType(t FieldType, value string) error {
+	switch t {
+	case FieldText, FieldHidden, FieldURL:
+		return nil
+	case FieldEmail:
+		if _, err := mail.ParseAddress(value); err != nil {
+			return errors.New("is not an email address")
+		}
+	case FieldDate:
+		if _, err := time.Parse(DateLayout, value); err != nil {
+			return errors.New("is not a date (YYYY-MM-DD)")
+		}
+	default:
+		return fmt.Errorf("has unknown type %q", t)
+	}
+	return nil
+}
+
+// CustomField returns the first custom field with the given name,
+// case-insensitively
+func (p *PasswordEntry) CustomField(name string) (*CustomField, bool) {
+	for i := range p.CustomFields {
+		if strings.EqualFold(p.CustomFields[i].Name, name) {
+			return &p.CustomFields[i], true
+		}
+	}
+	return nil, false
+}
+
+// customField finds a custom field by name, case-insensitively
+func (f *EntryFields) customField(name string) (CustomField, bool) {
+	for _, field := range f.CustomFields {
+		if strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return CustomField{}, false
+}
+
+// SetCustomField replaces the field with the same name, or appends it
+func (f *EntryFields) SetCustomField(field CustomField) {
+	for i := range f.CustomFields {
+		if strings.EqualFold(f.CustomFields[i].Name, field.Name) {
+			f.CustomFields[i] = field
+			return
+		}
+	}
+	f.CustomFields = append(f.CustomFields, field)
+}
+
+// RemoveCustomField deletes the field with the given name, reporting whether
+// there was one
+func (f *EntryFields) RemoveCustomField(name string) bool {
+	for i := range f.CustomFields {
+		if strings.EqualFold(f.CustomFields[i].Name, name) {
+			f.CustomFields = append(f.CustomFields[:i:i], f.CustomFields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// cloneFields copies custom fields so entries never share a backing array
+func cloneFields(fields []CustomField) []CustomField {
+	if len(fields) == 0 {
+		return nil
+	}
+	return append([]CustomField(nil), fields...)
+}
@@ -0,0 +1,300 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PathSeparator separates folder names in a path such as "infra/aws"
+const PathSeparator = "/"
+
+var (
+	ErrFolderNotFound    = errors.New("folder not found")
+	ErrFolderExists      = errors.New("folder already exists")
+	ErrInvalidFolderName = errors.New("invalid folder name")
+)
+
+// Folder is a node of the vault's folder tree. Entries and folders refer to
+// their parent by ID; an empty ID is the top level.
+type Folder struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidateFolderName checks a name can be used in a path
+func ValidateFolderName(name string) error {
+	switch {
+	case strings.TrimSpace(name) == "":
+		return fmt.Errorf("%w: name required", ErrInvalidFolderName)
+	case strings.Contains(name, PathSeparator):
+		return fmt.Errorf("%w: %q contains %q", ErrInvalidFolderName, name, PathSeparator)
+	}
+	return nil
+}
+
+// GetFolder retrieves a folder by ID
+func (v *Vault) GetFolder(id string) (*Folder, bool) {
+	for i := range v.Folders {
+		if v.Folders[i].ID == id {
+			return &v.Folders[i], true
+		}
+	}
+	return nil, false
+}
+
+// Subfolders returns the folders directly inside parentID, sorted by name
+func (v *Vault) Subfolders(parentID string) []Folder {
+	var folders []Folder
+	for _, folder := range v.Folders {
+		if folder.ParentID == parentID {
+			folders = append(folders, folder)
+		}
+	}
+	slices.SortFunc(folders, func(a, b Folder) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return folders
+}
+
+// EntriesIn returns the entries directly inside folderID
+func (v *Vault) EntriesIn(folderID string) []PasswordEntry {
+	var entries []PasswordEntry
+	for _, entry := range v.Entries {
+		if entry.FolderID == folderID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// FolderPath returns the path of a folder, or "" for the top level
+func (v *Vault) FolderPath(id string) string {
+	return FolderPath(v.Folders, id)
+}
+
+// FolderPath returns the path of the folder id within folders, for callers
+// holding the folder list without a vault
+func FolderPath(folders []Folder, id string) string {
+	byID := make(map[string]Folder, len(folders))
+	for _, folder := range folders {
+		byID[folder.ID] = folder
+	}
+
+	var names []string
+	for id != "" {
+		folder, ok := byID[id]
+		if !ok || len(names) > len(folders) {
+			break // Dangling or cyclic parent, see RepairFolders
+		}
+		names = append(names, folder.Name)
+		id = folder.ParentID
+	}
+	slices.Reverse(names)
+	return strings.Join(names, PathSeparator)
+}
+
+// EntryPath returns the path of an entry: its folder path and title
+func (v *Vault) EntryPath(entry *PasswordEntry) string {
+	if folder := v.FolderPath(entry.FolderID); folder != "" {
+		return folder + PathSeparator + entry.Title
+	}
+	return entry.Title
+}
+
+// ResolveFolder returns the ID of the folder at path, matching names
+// case-insensitively. An empty path is the top level.
+func (v *Vault) ResolveFolder(path string) (string, error) {
+	id := ""
+	for _, name := range splitPath(path) {
+		child, ok := v.childFolder(id, name)
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrFolderNotFound, path)
+		}
+		id = child.ID
+	}
+	return id, nil
+}
+
+// MkdirAll returns the ID of the folder at path, creating any missing
+// folders along it
+func (v *Vault) MkdirAll(path string) (string, error) {
+	id := ""
+	for _, name := range splitPath(path) {
+		child, ok := v.childFolder(id, name)
+		if !ok {
+			var err error
+			if child, err = v.CreateFolder(id, name); err != nil {
+				return "", err
+			}
+		}
+		id = child.ID
+	}
+	return id, nil
+}
+
+// CreateFolder adds a folder inside parentID, which must exist
+func (v *Vault) CreateFolder(parentID, name string) (*Folder, error) {
+	name = strings.TrimSpace(name)
+	if err := ValidateFolderName(name); err != nil {
+		return nil, err
+	}
+	if _, ok := v.GetFolder(parentID); parentID != "" && !ok {
+		return nil, ErrFolderNotFound
+	}
+	if _, ok := v.childFolder(parentID, name); ok {
+		return nil, fmt.Errorf("%w: %s", ErrFolderExists, name)
+	}
+
+	v.Folders = append(v.Folders, Folder{
+		ID:        generateID(),
+		Name:      name,
+		ParentID:  parentID,
+		UpdatedAt: time.Now(),
+	})
+	return &v.Folders[len(v.Folders)-1], nil
+}
+
+// RenameFolder gives a folder a new name, unique among its siblings
+func (v *Vault) RenameFolder(id, name string) error {
+	name = strings.TrimSpace(name)
+	if err := ValidateFolderName(name); err != nil {
+		return err
+	}
+	folder, ok := v.GetFolder(id)
+	if !ok {
+		return ErrFolderNotFound
+	}
+	if sibling, ok := v.childFolder(folder.ParentID, name); ok && sibling.ID != id {
+		return fmt.Errorf("%w: %s", ErrFolderExists, name)
+	}
+
+	folder.Name = name
+	folder.UpdatedAt = time.Now()
+	return nil
+}
+
+// DeleteFolder removes a folder, moving its entries and subfolders up into
+// its parent. It fails if a subfolder's name is already taken there.
+func (v *Vault) DeleteFolder(id string) error {
+	folder, ok := v.GetFolder(id)
+	if !ok {
+		return ErrFolderNotFound
+	}
+	parentID := folder.ParentID
+
+	for _, child := range v.Subfolders(id) {
+		if _, ok := v.childFolder(parentID, child.Name); ok {
+			return fmt.Errorf("%w: %s already has a folder named %s", ErrFolderExists, v.describeFolder(parentID), child.Name)
+		}
+	}
+
+	now := time.Now()
+	for i := range v.Folders {
+		if v.Folders[i].ParentID == id {
+			v.Folders[i].ParentID = parentID
+			v.Folders[i].UpdatedAt = now
+		}
+	}
+	for i := range v.Entries {
+		if v.Entries[i].FolderID == id {
+			v.Entries[i].FolderID = parentID
+			v.Entries[i].UpdatedAt = now
+		}
+	}
+	v.Folders = slices.DeleteFunc(v.Folders, func(f Folder) bool { return f.ID == id })
+	return nil
+}
+
+// MoveEntry puts an entry into folderID, which must exist
+func (v *Vault) MoveEntry(entryID, folderID string) error {
+	if _, ok := v.GetFolder(folderID); folderID != "" && !ok {
+		return ErrFolderNotFound
+	}
+	i, ok := v.position(entryID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNotFound, entryID)
+	}
+	if v.Entries[i].FolderID != folderID {
+		v.Entries[i].FolderID = folderID
+		v.Entries[i].UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+// RepairFolders moves entries and folders whose parent no longer exists, or
+// whose parents form a cycle, to the top level. Merging concurrent changes
+// can leave either behind.
+func (v *Vault) RepairFolders() {
+	for i := range v.Folders {
+		seen := map[string]bool{v.Folders[i].ID: true}
+		for parent := v.Folders[i].ParentID; parent != ""; {
+			folder, ok := v.GetFolder(parent)
+			if !ok || seen[parent] {
+				v.Folders[i].ParentID = ""
+				break
+			}
+			seen[parent] = true
+			parent = folder.ParentID
+		}
+	}
+	for i := range v.Entries {
+		if _, ok := v.GetFolder(v.Entries[i].FolderID); !ok {
+			v.Entries[i].FolderID = ""
+		}
+	}
+}
+
+// findByPath resolves "folder/path/title" to the entries with that title
+// in that folder
+func (v *Vault) findByPath(ref string) []int {
+	i := strings.LastIndex(ref, PathSeparator)
+	if i < 0 {
+		return nil
+	}
+	folderID, err := v.ResolveFolder(ref[:i])
+	if err != nil {
+		return nil
+	}
+
+	var matches []int
+	for j, entry := range v.Entries {
+		if entry.FolderID == folderID && strings.EqualFold(entry.Title, ref[i+1:]) {
+			matches = append(matches, j)
+		}
+	}
+	return matches
+}
+
+func (v *Vault) childFolder(parentID, name string) (*Folder, bool) {
+	for i := range v.Folders {
+		if v.Folders[i].ParentID == parentID && strings.EqualFold(v.Folders[i].Name, name) {
+			return &v.Folders[i], true
+		}
+	}
+	return nil, false
+}
+
+// describeFolder names a folder for messages
+func (v *Vault) describeFolder(id string) string {
+	if id == "" {
+		return "the top level"
+	}
+	return v.FolderPath(id)
+}
+
+// splitPath splits a folder path, ignoring empty segments and surrounding
+// separators
+func splitPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, PathSeparator) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Keys of the fields history records besides those in a kind's schema
+const (
+	KeyTitle = "title"
+	KeyOTP   = "otp"
+)
+
+// HistoryItem is a value an entry's field held before it was changed
+type HistoryItem struct {
+	Field     string    `json:"field"`            // Key of a built-in or kind field, or a custom field name
+	Custom    bool      `json:"custom,omitempty"` // Field is a custom field
+	Value     string    `json:"value"`
+	Concealed bool      `json:"concealed,omitempty"`
+	ChangedAt time.Time `json:"changed_at"` // When the value was replaced
+}
+
+// HistoryPolicy controls which previous values entries keep
+type HistoryPolicy struct {
+	Depth     int  // Previous values kept per field; 0 keeps none
+	AllFields bool // Keep every field, not just the password and other secrets
+}
+
+// DefaultHistoryPolicy returns the history kept when none is configured
+func DefaultHistoryPolicy() HistoryPolicy {
+	return HistoryPolicy{Depth: 10}
+}
+
+// SetHistoryPolicy sets the history UpdateEntry keeps for this vault
+func (v *Vault) SetHistoryPolicy(policy HistoryPolicy) {
+	v.history = &policy
+}
+
+// HistoryPolicy returns the history UpdateEntry keeps for this vault
+func (v *Vault) HistoryPolicy() HistoryPolicy {
+	if v.history == nil {
+		return DefaultHistoryPolicy()
+	}
+	return *v.history
+}
+
+// HistoryLabel names the field a history item belongs to
+func (p *PasswordEntry) HistoryLabel(item HistoryItem) string {
+	if item.Custom {
+		return item.Field
+	}
+	if field, ok := p.Schema().Field(item.Field); ok {
+		return field.Label
+	}
+	if item.Field == KeyOTP {
+		return "one-time password"
+	}
+	return item.Field
+}
+
+// recordHistory adds the values that fields is about to replace to the
+// entry's history, newest first, then drops the oldest beyond the depth
+func (p *PasswordEntry) recordHistory(fields EntryFields, policy HistoryPolicy, now time.Time) {
+	if policy.Depth <= 0 {
+		p.History = nil
+		return
+	}
+
+	var changed []HistoryItem
+	record := func(item HistoryItem, value string) {
+		if item.Value != "" && item.Value != value && (item.Concealed || policy.AllFields) {
+			item.ChangedAt = now
+			changed = append(changed, item)
+		}
+	}
+
+	old := p.Fields()
+	record(HistoryItem{Field: KeyTitle, Value: old.Title}, fields.Title)
+	for _, field := range p.Schema().Fields {
+		record(HistoryItem{Field: field.Key, Value: old.value(field.Key), Concealed: field.Concealed()}, fields.value(field.Key))
+	}
+	record(HistoryItem{Field: KeyOTP, Value: old.OTP, Concealed: true}, fields.OTP)
+	for _, field := range old.CustomFields {
+		updated, _ := fields.customField(field.Name)
+		record(HistoryItem{Field: field.Name, Custom: true, Value: field.Value, Concealed: field.Concealed}, updated.Value)
+	}
+
+	history := append(changed, p.History...)
+	type fieldKey struct {
+		name   string
+		custom bool
+	}
+	kept := make(map[fieldKey]int)
+	p.History = history[:0]
+	for _, item := range history {
+		key := fieldKey{item.Field, item.Custom}
+		if kept[key] < policy.Depth {
+			kept[key]++
+			p.History = append(p.History, item)
+		}
+	}
+	if len(p.History) == 0 {
+		p.History = nil
+	}
+}
+
+// Restore sets the field a history item belongs to back to its value. A
+// custom field removed since is added again.
+func (f *EntryFields) Restore(item HistoryItem) error {
+	if item.Custom {
+		restored := CustomField{Name: item.Field, Type: FieldText, Concealed: item.Concealed}
+		if item.Concealed {
+			restored.Type = FieldHidden
+		}
+		if field, ok := f.customField(item.Field); ok {
+			restored = field
+		}
+		restored.Value = item.Value
+		f.SetCustomField(restored)
+		return nil
+	}
+
+	switch item.Field {
+	case KeyTitle:
+		f.Title = item.Value
+	case KeyOTP:
+		f.OTP = item.Value
+	default:
+		if _, ok := SchemaFor(f.Kind).Field(item.Field); !ok {
+			return fmt.Errorf("%w: %s entries have no %s", ErrInvalidEntry, SchemaFor(f.Kind).Label, item.Field)
+		}
+		f.SetValue(item.Field, item.Value)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// entryIndex finds entries by ID without going through them all, and keeps
+// the text each entry is searched by. Vault methods keep it up to date as
+// they change entries; positions are rebuilt if entries were changed some
+// other way.
+type entryIndex struct {
+	positions map[string]int         // Where each entry is in Entries, by ID
+	texts     map[string]*SearchText // Search text by entry ID
+}
+
+// searchFields are the fields a word without a field is searched in, in
+// the order a search text keeps their values
+var searchFields = [...]string{"title", "user", "url", "notes", "tag", "field"}
+
+// SearchText is what searches read of an entry, lower-cased once rather
+// than on every key typed into a search. It is never changed once built,
+// so a search can go on reading it while the entry is edited.
+type SearchText struct {
+	id               string
+	kind             string
+	folderID         string
+	favorite         bool
+	created, updated time.Time
+	title            []rune                 // As written, to find word starts
+	lowerTitle       []rune                 // Lower-cased rune by rune, so positions match title
+	values           []string               // Lower-cased values of searchFields, in order
+	ends             [len(searchFields)]int // Where each field's values end in values
+}
+
+// newSearchText builds the search text of an entry
+func newSearchText(entry *PasswordEntry) *SearchText {
+	text := &SearchText{
+		id:         entry.ID,
+		kind:       string(entry.Schema().Kind),
+		folderID:   entry.FolderID,
+		favorite:   entry.Favorite,
+		created:    entry.CreatedAt,
+		updated:    entry.UpdatedAt,
+		title:      []rune(entry.Title),
+		lowerTitle: lowerRunes(entry.Title),
+	}
+	for i, field := range searchFields {
+		for _, value := range entry.searchValues(field) {
+			text.values = append(text.values, strings.ToLower(value))
+		}
+		text.ends[i] = len(text.values)
+	}
+	return text
+}
+
+// valuesOf returns the lower-cased values a term scoped to field is matched
+// against, those of every field in searchFields for no field. Regular
+// expressions ignore case, so they match these as they would the originals.
+func (t *SearchText) valuesOf(field string, folders []Folder) []string {
+	switch field {
+	case "":
+		return t.values
+	case "kind":
+		return []string{t.kind}
+	case "folder":
+		return []string{strings.ToLower(FolderPath(folders, t.folderID))}
+	}
+	start := 0
+	for i, name := range searchFields {
+		if name == field {
+			return t.values[start:t.ends[i]]
+		}
+		start = t.ends[i]
+	}
+	return nil
+}
+
+// SearchText returns the text an entry of the vault is searched by, built
+// the first time it is asked for and again once the entry has changed
+func (v *Vault) SearchText(entry *PasswordEntry) *SearchText {
+	index := v.indexed()
+	text, ok := index.texts[entry.ID]
+	if !ok || !text.updated.Equal(entry.UpdatedAt) || text.folderID != entry.FolderID {
+		text = newSearchText(entry)
+		index.texts[entry.ID] = text
+	}
+	return text
+}
+
+// SetEntries replaces every entry, as merging changes made elsewhere does
+func (v *Vault) SetEntries(entries []PasswordEntry) {
+	v.Entries = entries
+	v.reindex()
+}
+
+func (v *Vault) indexed() *entryIndex {
+	if v.index == nil {
+		v.index = &entryIndex{texts: make(map[string]*SearchText)}
+	}
+	return v.index
+}
+
+// position returns where the entry with id is in Entries
+func (v *Vault) position(id string) (int, bool) {
+	index := v.indexed()
+	i, ok := index.positions[id]
+	switch {
+	case ok && i < len(v.Entries) && v.Entries[i].ID == id:
+		return i, true
+	case !ok && index.positions != nil && len(index.positions) == len(v.Entries):
+		return 0, false
+	}
+
+	// Entries were added, removed or moved without the index
+	v.reindex()
+	i, ok = index.positions[id]
+	return i, ok
+}
+
+// reindex finds every entry's position again, dropping the search text of
+// entries no longer in the vault
+func (v *Vault) reindex() {
+	index := v.indexed()
+	index.positions = make(map[string]int, len(v.Entries))
+	for i := range v.Entries {
+		index.positions[v.Entries[i].ID] = i
+	}
+	for id := range index.texts {
+		if _, ok := index.positions[id]; !ok {
+			delete(index.texts, id)
+		}
+	}
+}
+
+// appendEntry adds an entry after the others, returning it as stored
+func (v *Vault) appendEntry(entry PasswordEntry) *PasswordEntry {
+	v.Entries = append(v.Entries, entry)
+	if v.index != nil && v.index.positions != nil {
+		v.index.positions[entry.ID] = len(v.Entries) - 1
+	}
+	return &v.Entries[len(v.Entries)-1]
+}
+
+// replaceEntry stores a new version of the entry at position i
+func (v *Vault) replaceEntry(i int, entry PasswordEntry) {
+	if v.index != nil {
+		delete(v.index.texts, v.Entries[i].ID)
+	}
+	v.Entries[i] = entry
+	if v.index != nil && v.index.positions != nil {
+		v.index.positions[entry.ID] = i
+	}
+}
+
+// removeEntry takes out the entry at position i, moving up those after it,
+// and returns it
+func (v *Vault) removeEntry(i int) PasswordEntry {
+	entry := v.Entries[i]
+	v.Entries = slices.Delete(v.Entries, i, i+1)
+	if index := v.index; index != nil {
+		delete(index.texts, entry.ID)
+		if index.positions != nil {
+			delete(index.positions, entry.ID)
+			for j := i; j < len(v.Entries); j++ {
+				index.positions[v.Entries[j].ID] = j
+			}
+		}
+	}
+	return entry
+}
@@ -0,0 +1,176 @@
+package models
+
+import "time"
+
+// Conflict is an entry changed differently on both sides of a merge. A nil
+// side means the entry was deleted there.
+type Conflict struct {
+	ID         string
+	Local      *PasswordEntry
+	Remote     *PasswordEntry
+	RemoteWins bool      // How the merge provisionally resolved it
+	DeletedAt  time.Time // When the nil side moved the entry to the trash, if it is still there
+}
+
+// MergeEntries performs a three-way merge of entry lists keyed on ID. base is
+// the common ancestor both local and remote started from; an entry counts as
+// changed on a side when its UpdatedAt differs from base. Changes made on
+// only one side are applied. Entries changed on both sides are reported as
+// conflicts and provisionally resolved in favour of the most recent update,
+// or of the surviving version when one side deleted the entry.
+func MergeEntries(base, local, remote []PasswordEntry) ([]PasswordEntry, []Conflict) {
+	baseByID := indexEntries(base)
+	localByID := indexEntries(local)
+	remoteByID := indexEntries(remote)
+
+	var merged []PasswordEntry
+	var conflicts []Conflict
+
+	for _, l := range local {
+		b, inBase := baseByID[l.ID]
+		r, inRemote := remoteByID[l.ID]
+
+		switch {
+		case !inRemote && !inBase:
+			// Added locally
+			merged = append(merged, l)
+
+		case !inRemote:
+			// Deleted remotely; keep only if we changed it meanwhile
+			if changedSince(l, b) {
+				conflicts = append(conflicts, Conflict{ID: l.ID, Local: entryPtr(l)})
+				merged = append(merged, l)
+			}
+
+		case inBase && !changedSince(r, b):
+			merged = append(merged, l)
+
+		case inBase && !changedSince(l, b):
+			merged = append(merged, r)
+
+		case l.UpdatedAt.Equal(r.UpdatedAt):
+			// Same update seen on both sides
+			merged = append(merged, l)
+
+		default:
+			remoteWins := r.UpdatedAt.After(l.UpdatedAt)
+			conflicts = append(conflicts, Conflict{ID: l.ID, Local: entryPtr(l), Remote: entryPtr(r), RemoteWins: remoteWins})
+			if remoteWins {
+				merged = append(merged, r)
+			} else {
+				merged = append(merged, l)
+			}
+		}
+	}
+
+	for _, r := range remote {
+		if _, inLocal := localByID[r.ID]; inLocal {
+			continue
+		}
+
+		b, inBase := baseByID[r.ID]
+		switch {
+		case !inBase:
+			// Added remotely
+			merged = append(merged, r)
+
+		case changedSince(r, b):
+			// Deleted locally but changed remotely; keep the remote change
+			conflicts = append(conflicts, Conflict{ID: r.ID, Remote: entryPtr(r), RemoteWins: true})
+			merged = append(merged, r)
+		}
+	}
+
+	return merged, conflicts
+}
+
+// MergeFolders performs a three-way merge of folder lists keyed on ID, like
+// MergeEntries. Folders changed on both sides keep the most recent version
+// without being reported, since a folder holds nothing secret to lose; the
+// caller should run RepairFolders on the result.
+func MergeFolders(base, local, remote []Folder) []Folder {
+	baseByID := make(map[string]Folder, len(base))
+	for _, folder := range base {
+		baseByID[folder.ID] = folder
+	}
+	remoteByID := make(map[string]Folder, len(remote))
+	for _, folder := range remote {
+		remoteByID[folder.ID] = folder
+	}
+
+	// A folder missing on one side survives if it is new or was changed on
+	// the other side since the base
+	keep := func(folder Folder) bool {
+		b, inBase := baseByID[folder.ID]
+		return !inBase || !folder.UpdatedAt.Equal(b.UpdatedAt)
+	}
+
+	var merged []Folder
+	localIDs := make(map[string]bool, len(local))
+	for _, l := range local {
+		localIDs[l.ID] = true
+		r, inRemote := remoteByID[l.ID]
+		switch {
+		case !inRemote:
+			if keep(l) {
+				merged = append(merged, l)
+			}
+		case r.UpdatedAt.After(l.UpdatedAt):
+			merged = append(merged, r)
+		default:
+			merged = append(merged, l)
+		}
+	}
+	for _, r := range remote {
+		if !localIDs[r.ID] && keep(r) {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
+
+// ResolveConflict settles a conflict on the local or remote version of the
+// entry. Choosing the side that deleted it moves it to the trash, as deleted
+// then, so it can still be restored; otherwise the chosen version replaces
+// the entry, or is added back and taken out of the trash.
+func (v *Vault) ResolveConflict(conflict Conflict, useRemote bool) {
+	chosen := conflict.Local
+	if useRemote {
+		chosen = conflict.Remote
+	}
+	v.PurgeEntry(conflict.ID)
+	i, ok := v.position(conflict.ID)
+
+	if chosen == nil {
+		if ok {
+			deletedAt := conflict.DeletedAt
+			if deletedAt.IsZero() {
+				deletedAt = time.Now()
+			}
+			v.trashEntry(i, deletedAt)
+		}
+		return
+	}
+	if ok {
+		v.replaceEntry(i, *chosen)
+	} else {
+		v.appendEntry(*chosen)
+	}
+}
+
+// changedSince reports whether entry was updated after the base version
+func changedSince(entry, base PasswordEntry) bool {
+	return !entry.UpdatedAt.Equal(base.UpdatedAt)
+}
+
+func indexEntries(entries []PasswordEntry) map[string]PasswordEntry {
+	byID := make(map[string]PasswordEntry, len(entries))
+	for _, entry := range entries {
+		byID[entry.ID] = entry
+	}
+	return byID
+}
+
+func entryPtr(entry PasswordEntry) *PasswordEntry {
+	return &entry
+}
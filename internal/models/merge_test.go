@@ -0,0 +1,251 @@
+package models
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var (
+	mergeBase   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mergeLocal  = mergeBase.Add(time.Hour)
+	mergeRemote = mergeBase.Add(2 * time.Hour) // Remote edits are the more recent
+)
+
+func TestMergeEntries(t *testing.T) {
+	base := PasswordEntry{ID: "e", Title: "base", UpdatedAt: mergeBase}
+	edited := func(title string, at time.Time) *PasswordEntry {
+		entry := base
+		entry.Title, entry.UpdatedAt = title, at
+		return &entry
+	}
+
+	tests := []struct {
+		name          string
+		inBase        bool
+		local, remote *PasswordEntry // Nil where the entry is missing
+		want          string         // Title kept, empty if none
+		conflict      bool
+		remoteWins    bool
+	}{
+		{name: "unchanged", inBase: true, local: &base, remote: &base, want: "base"},
+		{name: "edited remotely", inBase: true, local: &base, remote: edited("remote", mergeRemote), want: "remote"},
+		{name: "edited locally", inBase: true, local: edited("local", mergeLocal), remote: &base, want: "local"},
+		{name: "deleted remotely", inBase: true, local: &base},
+		{name: "deleted locally", inBase: true, remote: &base},
+		{name: "deleted on both sides", inBase: true},
+		{name: "added locally", local: edited("local", mergeLocal), want: "local"},
+		{name: "added remotely", remote: edited("remote", mergeRemote), want: "remote"},
+		{name: "same edit on both sides", inBase: true, local: edited("same", mergeLocal), remote: edited("same", mergeLocal), want: "same"},
+
+		// Conflicts
+		{
+			name: "edited on both sides, remote later", inBase: true,
+			local: edited("local", mergeLocal), remote: edited("remote", mergeRemote),
+			want: "remote", conflict: true, remoteWins: true,
+		},
+		{
+			name: "edited on both sides, local later", inBase: true,
+			local: edited("local", mergeRemote), remote: edited("remote", mergeLocal),
+			want: "local", conflict: true,
+		},
+		{
+			name: "edited locally, deleted remotely", inBase: true,
+			local: edited("local", mergeLocal),
+			want:  "local", conflict: true,
+		},
+		{
+			name: "deleted locally, edited remotely", inBase: true,
+			remote: edited("remote", mergeRemote),
+			want:   "remote", conflict: true, remoteWins: true,
+		},
+	}
+
+	other := PasswordEntry{ID: "other", Title: "other", UpdatedAt: mergeBase}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseEntries := []PasswordEntry{other}
+			if tt.inBase {
+				baseEntries = append(baseEntries, base)
+			}
+			local, remote := []PasswordEntry{other}, []PasswordEntry{other}
+			if tt.local != nil {
+				local = append(local, *tt.local)
+			}
+			if tt.remote != nil {
+				remote = append(remote, *tt.remote)
+			}
+
+			merged, conflicts := MergeEntries(baseEntries, local, remote)
+
+			var titles []string
+			for _, entry := range merged {
+				titles = append(titles, entry.Title)
+			}
+			want := []string{"other"}
+			if tt.want != "" {
+				want = append(want, tt.want)
+			}
+			if !slices.Equal(titles, want) {
+				t.Errorf("merged %q, want %q", titles, want)
+			}
+
+			switch {
+			case !tt.conflict && len(conflicts) > 0:
+				t.Errorf("unexpected conflicts %+v", conflicts)
+			case tt.conflict && len(conflicts) != 1:
+				t.Errorf("got %d conflicts, want 1", len(conflicts))
+			case tt.conflict:
+				c := conflicts[0]
+				if c.ID != "e" || c.RemoteWins != tt.remoteWins || (c.Local == nil) != (tt.local == nil) || (c.Remote == nil) != (tt.remote == nil) {
+					t.Errorf("conflict %+v", c)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeFolders(t *testing.T) {
+	base := Folder{ID: "f", Name: "base", UpdatedAt: mergeBase}
+	renamed := func(name string, at time.Time) *Folder {
+		folder := base
+		folder.Name, folder.UpdatedAt = name, at
+		return &folder
+	}
+
+	tests := []struct {
+		name          string
+		inBase        bool
+		local, remote *Folder
+		want          string
+	}{
+		{name: "unchanged", inBase: true, local: &base, remote: &base, want: "base"},
+		{name: "renamed remotely", inBase: true, local: &base, remote: renamed("remote", mergeRemote), want: "remote"},
+		{name: "renamed locally", inBase: true, local: renamed("local", mergeLocal), remote: &base, want: "local"},
+		{name: "renamed on both sides, remote later", inBase: true, local: renamed("local", mergeLocal), remote: renamed("remote", mergeRemote), want: "remote"},
+		{name: "renamed on both sides, local later", inBase: true, local: renamed("local", mergeRemote), remote: renamed("remote", mergeLocal), want: "local"},
+		{name: "deleted remotely", inBase: true, local: &base},
+		{name: "deleted locally", inBase: true, remote: &base},
+		{name: "deleted on both sides", inBase: true},
+		{name: "renamed locally, deleted remotely", inBase: true, local: renamed("local", mergeLocal), want: "local"},
+		{name: "deleted locally, renamed remotely", inBase: true, remote: renamed("remote", mergeRemote), want: "remote"},
+		{name: "added locally", local: renamed("local", mergeLocal), want: "local"},
+		{name: "added remotely", remote: renamed("remote", mergeRemote), want: "remote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var baseFolders, local, remote []Folder
+			if tt.inBase {
+				baseFolders = append(baseFolders, base)
+			}
+			if tt.local != nil {
+				local = append(local, *tt.local)
+			}
+			if tt.remote != nil {
+				remote = append(remote, *tt.remote)
+			}
+
+			var names []string
+			for _, folder := range MergeFolders(baseFolders, local, remote) {
+				names = append(names, folder.Name)
+			}
+			var want []string
+			if tt.want != "" {
+				want = append(want, tt.want)
+			}
+			if !slices.Equal(names, want) {
+				t.Errorf("merged %q, want %q", names, want)
+			}
+		})
+	}
+}
+
+func TestMergeTrash(t *testing.T) {
+	base := TrashedEntry{PasswordEntry: PasswordEntry{ID: "t", Title: "base"}, DeletedAt: mergeBase}
+	trashed := func(title string, at time.Time) *TrashedEntry {
+		entry := base
+		entry.Title, entry.DeletedAt = title, at
+		return &entry
+	}
+
+	tests := []struct {
+		name          string
+		inBase        bool
+		local, remote *TrashedEntry
+		live          bool // The entry is in the merged vault
+		want          string
+	}{
+		{name: "unchanged", inBase: true, local: &base, remote: &base, want: "base"},
+		{name: "trashed locally", local: trashed("local", mergeLocal), want: "local"},
+		{name: "trashed remotely", remote: trashed("remote", mergeRemote), want: "remote"},
+		{name: "trashed on both sides, remote later", local: trashed("local", mergeLocal), remote: trashed("remote", mergeRemote), want: "remote"},
+		{name: "trashed on both sides, local later", local: trashed("local", mergeRemote), remote: trashed("remote", mergeLocal), want: "local"},
+		{name: "purged remotely", inBase: true, local: &base},
+		{name: "purged locally", inBase: true, remote: &base},
+		{name: "purged on both sides", inBase: true},
+		{name: "restored and trashed again locally, purged remotely", inBase: true, local: trashed("local", mergeLocal), want: "local"},
+		{name: "purged locally, restored and trashed again remotely", inBase: true, remote: trashed("remote", mergeRemote), want: "remote"},
+		{name: "trashed locally, kept by a conflict", local: trashed("local", mergeLocal), live: true},
+		{name: "trashed remotely, kept by a conflict", remote: trashed("remote", mergeRemote), live: true},
+		{name: "restored locally", inBase: true, remote: &base, live: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var baseTrash, local, remote []TrashedEntry
+			if tt.inBase {
+				baseTrash = append(baseTrash, base)
+			}
+			if tt.local != nil {
+				local = append(local, *tt.local)
+			}
+			if tt.remote != nil {
+				remote = append(remote, *tt.remote)
+			}
+			var entries []PasswordEntry
+			if tt.live {
+				entries = append(entries, base.PasswordEntry)
+			}
+
+			var titles []string
+			for _, entry := range MergeTrash(baseTrash, local, remote, entries) {
+				titles = append(titles, entry.Title)
+			}
+			var want []string
+			if tt.want != "" {
+				want = append(want, tt.want)
+			}
+			if !slices.Equal(titles, want) {
+				t.Errorf("merged %q, want %q", titles, want)
+			}
+		})
+	}
+}
+
+func TestResolveConflictByDeleting(t *testing.T) {
+	deletedAt := mergeLocal
+	entry := PasswordEntry{ID: "e", Title: "remote", UpdatedAt: mergeRemote}
+	vault := NewVault(nil)
+	vault.AddEntry(&entry) // The merge kept the remote edit
+
+	conflict := Conflict{ID: "e", Remote: &entry, RemoteWins: true, DeletedAt: deletedAt}
+	vault.ResolveConflict(conflict, false)
+
+	if _, ok := vault.GetEntry("e"); ok {
+		t.Fatal("entry still in the vault")
+	}
+	trashed, ok := vault.GetTrashed("e")
+	if !ok {
+		t.Fatal("entry not in the trash")
+	}
+	if !trashed.DeletedAt.Equal(deletedAt) || trashed.Title != "remote" {
+		t.Errorf("trashed %+v, want the remote version deleted at %s", trashed, deletedAt)
+	}
+
+	// Changing our mind brings it back out of the trash
+	vault.ResolveConflict(conflict, true)
+	if _, ok := vault.GetEntry("e"); !ok || len(vault.Trash) != 0 {
+		t.Errorf("entry not restored: entries %+v, trash %+v", vault.Entries, vault.Trash)
+	}
+}
@@ -3,60 +3,114 @@ package models
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 )
 
+var (
+	ErrNotFound  = errors.New("entry not found")
+	ErrAmbiguous = errors.New("entry is ambiguous")
+)
+
 // PasswordEntry represents a single password entry in the vault
 type PasswordEntry struct {
-	ID        string    `json:"id"`
-	Title     string    `json:"title"`
-	Username  string    `json:"username,omitempty"`
-	Password  string    `json:"password"`
-	URL       string    `json:"url,omitempty"`
-	Notes     string    `json:"notes,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID           string            `json:"id"`
+	Kind         Kind              `json:"kind,omitempty"` // Empty for a login
+	Title        string            `json:"title"`
+	Username     string            `json:"username,omitempty"`
+	Password     string            `json:"password"`
+	URL          string            `json:"url,omitempty"`
+	Notes        string            `json:"notes,omitempty"`
+	Details      map[string]string `json:"details,omitempty"` // Kind-specific fields, keyed as in the kind's schema
+	OTP          string            `json:"otp,omitempty"`     // otpauth:// URI or base32 TOTP seed
+	CustomFields []CustomField     `json:"custom_fields,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`      // Normalized and sorted
+	FolderID     string            `json:"folder_id,omitempty"` // Empty for the top level
+	Favorite     bool              `json:"favorite,omitempty"`  // Ranked first in searches
+	History      []HistoryItem     `json:"history,omitempty"`   // Previous values, newest first
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
 }
 
 // Vault represents the entire password vault
 type Vault struct {
 	Entries []PasswordEntry `json:"entries"`
+	Folders []Folder        `json:"folders,omitempty"`
+	Trash   []TrashedEntry  `json:"trash,omitempty"` // Deleted entries, oldest first
 	Salt    []byte          `json:"salt"`
+
+	history *HistoryPolicy // Nil keeps the default history
+	index   *entryIndex    // Built when first needed
+}
+
+// EntryFields are the user-editable fields of an entry
+type EntryFields struct {
+	Kind         Kind
+	Title        string
+	Username     string
+	Password     string
+	URL          string
+	Notes        string
+	Details      map[string]string
+	OTP          string
+	CustomFields []CustomField
+	Tags         []string
+	FolderID     string
+	Favorite     bool
 }
 
 // NewPasswordEntry creates a new password entry with generated ID and timestamps
-func NewPasswordEntry(title, username, password, url, notes string) *PasswordEntry {
+func NewPasswordEntry(fields EntryFields) *PasswordEntry {
 	now := time.Now()
-	return &PasswordEntry{
+	entry := &PasswordEntry{
 		ID:        generateID(),
-		Title:     title,
-		Username:  username,
-		Password:  password,
-		URL:       url,
-		Notes:     notes,
 		CreatedAt: now,
-		UpdatedAt: now,
 	}
+	entry.Update(fields)
+	entry.UpdatedAt = now
+	return entry
 }
 
-// Update updates the password entry fields and timestamp
-func (p *PasswordEntry) Update(title, username, password, url, notes string) {
-	p.Title = title
-	p.Username = username
-	p.Password = password
-	p.URL = url
-	p.Notes = notes
-	p.UpdatedAt = time.Now()
+// Fields returns the user-editable fields of the entry
+func (p *PasswordEntry) Fields() EntryFields {
+	return EntryFields{
+		Kind:         p.Kind,
+		Title:        p.Title,
+		Username:     p.Username,
+		Password:     p.Password,
+		URL:          p.URL,
+		Notes:        p.Notes,
+		Details:      cloneDetails(p.Details),
+		OTP:          p.OTP,
+		CustomFields: cloneFields(p.CustomFields),
+		Tags:         slices.Clone(p.Tags),
+		FolderID:     p.FolderID,
+		Favorite:     p.Favorite,
+	}
 }
 
-// MatchesSearch checks if the entry matches a search query
-func (p *PasswordEntry) MatchesSearch(query string) bool {
-	query = strings.ToLower(query)
-	return strings.Contains(strings.ToLower(p.Title), query) ||
-		strings.Contains(strings.ToLower(p.Username), query) ||
-		strings.Contains(strings.ToLower(p.URL), query) ||
-		strings.Contains(strings.ToLower(p.Notes), query)
+// Update updates the password entry fields and timestamp. It keeps no
+// history; Vault.UpdateEntry does.
+func (p *PasswordEntry) Update(fields EntryFields) {
+	p.Kind = fields.Kind
+	if p.Kind == KindLogin {
+		p.Kind = "" // Stored as before kinds existed
+	}
+	p.Title = fields.Title
+	p.Username = fields.Username
+	p.Password = fields.Password
+	p.URL = fields.URL
+	p.Notes = fields.Notes
+	p.Details = cloneDetails(fields.Details)
+	p.OTP = fields.OTP
+	p.CustomFields = cloneFields(fields.CustomFields)
+	p.Tags = NormalizeTags(fields.Tags)
+	p.FolderID = fields.FolderID
+	p.Favorite = fields.Favorite
+	p.UpdatedAt = time.Now()
 }
 
 // NewVault creates a new empty vault with the given salt
@@ -69,54 +123,157 @@ func NewVault(salt []byte) *Vault {
 
 // AddEntry adds a new password entry to the vault
 func (v *Vault) AddEntry(entry *PasswordEntry) {
-	v.Entries = append(v.Entries, *entry)
+	v.appendEntry(*entry)
 }
 
-// UpdateEntry updates an existing entry in the vault
-func (v *Vault) UpdateEntry(id string, title, username, password, url, notes string) bool {
-	for i := range v.Entries {
-		if v.Entries[i].ID == id {
-			v.Entries[i].Update(title, username, password, url, notes)
-			return true
-		}
+// UpdateEntry updates an existing entry in the vault, keeping the values it
+// replaces in the entry's history
+func (v *Vault) UpdateEntry(id string, fields EntryFields) bool {
+	i, ok := v.position(id)
+	if !ok {
+		return false
+	}
+	v.Entries[i].recordHistory(fields, v.HistoryPolicy(), time.Now())
+	v.Entries[i].Update(fields)
+	delete(v.index.texts, id)
+	return true
+}
+
+// AdvanceOTP replaces an entry's one-time password URI without keeping the
+// old one in its history, as moving an HOTP counter on after generating a
+// code does
+func (v *Vault) AdvanceOTP(id, uri string) bool {
+	i, ok := v.position(id)
+	if !ok {
+		return false
 	}
-	return false
+	v.Entries[i].OTP = uri
+	v.Entries[i].UpdatedAt = time.Now()
+	delete(v.index.texts, id)
+	return true
 }
 
-// DeleteEntry removes an entry from the vault by ID
+// DeleteEntry moves an entry to the trash by ID
 func (v *Vault) DeleteEntry(id string) bool {
-	for i, entry := range v.Entries {
-		if entry.ID == id {
-			v.Entries = append(v.Entries[:i], v.Entries[i+1:]...)
-			return true
-		}
+	i, ok := v.position(id)
+	if !ok {
+		return false
 	}
-	return false
+	v.trashEntry(i, time.Now())
+	return true
 }
 
-// GetEntry retrieves an entry by ID
+// GetEntry retrieves a copy of an entry by ID
 func (v *Vault) GetEntry(id string) (*PasswordEntry, bool) {
-	for _, entry := range v.Entries {
-		if entry.ID == id {
-			return &entry, true
-		}
+	i, ok := v.position(id)
+	if !ok {
+		return nil, false
 	}
-	return nil, false
+	entry := v.Entries[i]
+	return &entry, true
 }
 
-// SearchEntries returns entries that match the search query
-func (v *Vault) SearchEntries(query string) []PasswordEntry {
+// SearchEntries returns entries that match the search query, as parsed by
+// ParseQuery, best first
+func (v *Vault) SearchEntries(query string, usage Usage) ([]PasswordEntry, error) {
 	if query == "" {
-		return v.Entries
+		return v.Entries, nil
+	}
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
 	}
 
 	var matches []PasswordEntry
-	for _, entry := range v.Entries {
-		if entry.MatchesSearch(query) {
-			matches = append(matches, entry)
+	for _, result := range v.Search(q, usage) {
+		matches = append(matches, *result.Entry)
+	}
+	return matches, nil
+}
+
+// FindEntry resolves ref to a single entry by ID, then path such as
+// "infra/aws/root", then exact title (case-insensitive), then unique title
+// substring
+func (v *Vault) FindEntry(ref string) (*PasswordEntry, error) {
+	if entry, ok := v.GetEntry(ref); ok {
+		return entry, nil
+	}
+	return v.pickEntry(ref, v.candidates(ref))
+}
+
+// ResolveEntry resolves ref like FindEntry, except that when several
+// entries fit it takes the one ranking best for ref as a search, counting
+// favourites and recent use, if no other ranks as high
+func (v *Vault) ResolveEntry(ref string, usage Usage) (*PasswordEntry, error) {
+	if entry, ok := v.GetEntry(ref); ok {
+		return entry, nil
+	}
+	candidates := v.candidates(ref)
+	if len(candidates) < 2 {
+		return v.pickEntry(ref, candidates)
+	}
+
+	query := textQuery(ref)
+	now := time.Now()
+	scores := make(map[int]int, len(candidates))
+	for _, i := range candidates {
+		scores[i] = query.Rank(v.SearchText(&v.Entries[i]), usage, now).Score
+	}
+	slices.SortStableFunc(candidates, func(a, b int) int {
+		return scores[b] - scores[a]
+	})
+	if scores[candidates[0]] > scores[candidates[1]] {
+		return &v.Entries[candidates[0]], nil
+	}
+	return v.pickEntry(ref, candidates)
+}
+
+// candidates lists the indexes of the entries ref could mean: those at
+// the path, or else with the title, or else containing it in the title
+func (v *Vault) candidates(ref string) []int {
+	lower := strings.ToLower(ref)
+	var exact, partial []int
+	for i, entry := range v.Entries {
+		title := strings.ToLower(entry.Title)
+		if title == lower {
+			exact = append(exact, i)
+		} else if strings.Contains(title, lower) {
+			partial = append(partial, i)
 		}
 	}
-	return matches
+
+	candidates := v.findByPath(ref)
+	if len(candidates) == 0 {
+		candidates = exact
+	}
+	if len(candidates) == 0 {
+		candidates = partial
+	}
+	return candidates
+}
+
+// pickEntry returns the only candidate, failing if there are none or more
+func (v *Vault) pickEntry(ref string, candidates []int) (*PasswordEntry, error) {
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case 1:
+		return &v.Entries[candidates[0]], nil
+	default:
+		var names []string
+		for _, i := range candidates {
+			names = append(names, fmt.Sprintf("%s (%s)", v.EntryPath(&v.Entries[i]), v.Entries[i].ID))
+		}
+		return nil, fmt.Errorf("%w: %q matches %s", ErrAmbiguous, ref, strings.Join(names, ", "))
+	}
+}
+
+// NormalizeURL adds an https:// scheme to URLs entered without one
+func NormalizeURL(url string) string {
+	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return "https://" + url
+	}
+	return url
 }
 
 // generateID creates a random hex ID for password entries
@@ -124,4 +281,4 @@ func generateID() string {
 	bytes := make([]byte, 8)
 	rand.Read(bytes)
 	return hex.EncodeToString(bytes)
-}
\ No newline at end of file
+}
@@ -0,0 +1,69 @@
+// Package modelstest builds vaults for tests and benchmarks
+package modelstest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"vault/internal/models"
+)
+
+var (
+	services = []string{"github", "gitlab", "aws", "google", "slack", "jira", "stripe", "azure", "docker", "npm", "heroku", "figma", "notion", "dropbox", "okta", "zoom"}
+	roles    = []string{"admin", "deploy", "ci", "personal", "work", "staging", "prod", "backup", "readonly", "billing"}
+	tags     = []string{"prod", "staging", "dev", "shared", "personal", "finance", "infra", "legacy"}
+)
+
+// The size of the vault benchmarks measure
+const (
+	benchEntries = 100000
+	benchFolders = 500
+)
+
+// BenchVault returns a vault of 100,000 generated entries in 500 folders,
+// the size every benchmark measures
+func BenchVault() *models.Vault {
+	vault := models.NewVault(nil)
+	Generate(vault, benchEntries, benchFolders)
+	return vault
+}
+
+// Generate fills the vault with count entries spread over folderCount
+// nested folders. The same arguments always give the same entries.
+func Generate(vault *models.Vault, count, folderCount int) {
+	rng := rand.New(rand.NewPCG(1, 2))
+
+	var folderIDs []string
+	for i := range folderCount {
+		parentID := ""
+		if i >= 10 {
+			parentID = folderIDs[i%10] // Ten top-level folders, the rest inside them
+		}
+		folder, err := vault.CreateFolder(parentID, fmt.Sprintf("%s-%d", roles[i%len(roles)], i))
+		if err == nil {
+			folderIDs = append(folderIDs, folder.ID)
+		}
+	}
+
+	for i := range count {
+		service := services[rng.IntN(len(services))]
+		fields := models.EntryFields{
+			Title:    fmt.Sprintf("%s %s %d", service, roles[rng.IntN(len(roles))], i),
+			Username: fmt.Sprintf("user%d@example.com", rng.IntN(count)),
+			Password: fmt.Sprintf("%x", rng.Uint64()),
+			URL:      "https://" + service + ".example.com/login",
+			Tags:     []string{tags[rng.IntN(len(tags))], tags[rng.IntN(len(tags))]},
+		}
+		if rng.IntN(4) == 0 {
+			fields.Notes = strings.Repeat("rotated quarterly by the platform team; ", 1+rng.IntN(3))
+		}
+		if rng.IntN(10) == 0 {
+			fields.CustomFields = []models.CustomField{{Name: "account", Value: fmt.Sprint(rng.IntN(1e6))}}
+		}
+		if len(folderIDs) > 0 && rng.IntN(10) > 0 {
+			fields.FolderID = folderIDs[rng.IntN(len(folderIDs))]
+		}
+		vault.AddEntry(models.NewPasswordEntry(fields))
+	}
+}
@@ -0,0 +1,462 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ErrInvalidQuery = errors.New("invalid query")
+
+// Query is a parsed search query. Its terms must all match, anywhere in the
+// entry, ignoring case. A term is a word, a "quoted phrase" or a
+// /regular expression/. The operators are:
+//
+//   - OR between terms lets either match
+//   - "-" in front of a term or group excludes what it matches
+//   - parentheses group terms
+//   - a field name and colon in front of a term scope it to that field, as
+//     in user:alice
+//   - created: and updated: compare dates, as in updated:<90d or
+//     created:>=2024-01-01
+type Query struct {
+	root  queryNode  // Nil matches every entry
+	words []termNode // Words and phrases searched for, which rank matches
+}
+
+// queryFields maps the field names a term can be scoped to, and their
+// aliases, to the field searched
+var queryFields = map[string]string{
+	"title":    "title",
+	"user":     "user",
+	"username": "user",
+	"url":      "url",
+	"notes":    "notes",
+	"note":     "notes",
+	"tag":      "tag",
+	"kind":     "kind",
+	"folder":   "folder",
+	"field":    "field",
+	"created":  "created",
+	"updated":  "updated",
+}
+
+// ParseQuery parses a search query. An empty query matches every entry.
+func ParseQuery(query string) (Query, error) {
+	return parseQuery(query, time.Now())
+}
+
+func parseQuery(query string, now time.Time) (Query, error) {
+	p := &queryParser{s: query, now: now}
+	root, err := p.parseOr()
+	if err == nil && p.pos < len(p.s) {
+		err = errors.New("unmatched )")
+	}
+	if err != nil {
+		return Query{}, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	if and, ok := root.(andNode); ok && len(and) == 0 {
+		root = nil
+	}
+	return Query{root: root, words: p.words}, nil
+}
+
+// textQuery searches for each word of text, as typed rather than parsed
+func textQuery(text string) Query {
+	var q Query
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		q.words = append(q.words, termNode{text: word, runes: []rune(word), fuzzy: true})
+	}
+	return q
+}
+
+// Match reports whether the entry with the search text matches the query.
+// Folders resolve the entry's folder path for folder: terms.
+func (q Query) Match(text *SearchText, folders []Folder) bool {
+	return q.root == nil || q.root.match(text, folders)
+}
+
+type queryNode interface {
+	match(text *SearchText, folders []Folder) bool
+}
+
+type (
+	andNode []queryNode
+	orNode  []queryNode
+	notNode struct{ node queryNode }
+)
+
+func (n andNode) match(text *SearchText, folders []Folder) bool {
+	for _, node := range n {
+		if !node.match(text, folders) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n orNode) match(text *SearchText, folders []Folder) bool {
+	for _, node := range n {
+		if node.match(text, folders) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n notNode) match(text *SearchText, folders []Folder) bool {
+	return !n.node.match(text, folders)
+}
+
+// termNode matches text in a field, or in any searchable field
+type termNode struct {
+	field string         // Empty for any field
+	text  string         // Lower-cased
+	runes []rune         // Text as runes, to match titles by
+	exact bool           // The whole value must equal text, for tags and kinds
+	fuzzy bool           // A word also ranks by its letters in order in the title
+	re    *regexp.Regexp // Set for a regular expression instead of text
+}
+
+func (n termNode) match(text *SearchText, folders []Folder) bool {
+	for _, value := range text.valuesOf(n.field, folders) {
+		switch {
+		case n.re != nil:
+			if n.re.MatchString(value) {
+				return true
+			}
+		case n.exact:
+			if value == n.text {
+				return true
+			}
+		case strings.Contains(value, n.text):
+			return true
+		}
+	}
+	return false
+}
+
+// dateNode matches a creation or update time in [from, to); a zero bound
+// is open
+type dateNode struct {
+	field    string
+	from, to time.Time
+}
+
+func (n dateNode) match(text *SearchText, _ []Folder) bool {
+	t := text.updated
+	if n.field == "created" {
+		t = text.created
+	}
+	return (n.from.IsZero() || !t.Before(n.from)) && (n.to.IsZero() || t.Before(n.to))
+}
+
+// searchValues returns the entry's values for one of searchFields. Custom
+// fields match by name, and by value unless concealed, as do kind-specific
+// details.
+func (p *PasswordEntry) searchValues(field string) []string {
+	switch field {
+	case "title":
+		return []string{p.Title}
+	case "user":
+		return []string{p.Username}
+	case "url":
+		return []string{p.URL}
+	case "notes":
+		return []string{p.Notes}
+	case "tag":
+		return p.Tags
+	case "field":
+		return p.fieldValues()
+	}
+	return nil
+}
+
+// fieldValues lists the entry's non-concealed details, and its custom field
+// names and non-concealed values
+func (p *PasswordEntry) fieldValues() []string {
+	var values []string
+	for _, field := range p.Schema().Fields {
+		if !field.Builtin() && !field.Concealed() && p.Details[field.Key] != "" {
+			values = append(values, p.Details[field.Key])
+		}
+	}
+	for _, field := range p.CustomFields {
+		values = append(values, field.Name)
+		if !field.Concealed {
+			values = append(values, field.Value)
+		}
+	}
+	return values
+}
+
+// queryParser is a recursive descent parser over the query text
+type queryParser struct {
+	s       string
+	pos     int
+	now     time.Time  // Ages such as 90d count back from now
+	negated int        // How many '-' apply to the term being parsed
+	words   []termNode // Words and phrases that are not negated
+}
+
+// parseOr parses terms separated by OR, up to the end or a closing
+// parenthesis
+func (p *queryParser) parseOr() (queryNode, error) {
+	var alternatives orNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consumeOr() {
+			if len(alternatives) == 0 {
+				return node, nil
+			}
+			if len(node.(andNode)) == 0 {
+				return nil, errors.New("OR needs a term after it")
+			}
+			return append(alternatives, node), nil
+		}
+		if len(node.(andNode)) == 0 {
+			return nil, errors.New("OR needs a term before it")
+		}
+		alternatives = append(alternatives, node)
+	}
+}
+
+// parseAnd parses terms that must all match, up to OR, the end or a closing
+// parenthesis
+func (p *queryParser) parseAnd() (queryNode, error) {
+	terms := andNode{}
+	for {
+		p.skipSpace()
+		if p.pos == len(p.s) || p.s[p.pos] == ')' || p.atOr() {
+			return terms, nil
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, node)
+	}
+}
+
+// parseUnary parses a term or group, negated by a leading '-'
+func (p *queryParser) parseUnary() (queryNode, error) {
+	switch {
+	case p.s[p.pos] == '-' && p.pos+1 < len(p.s) && !isSpace(p.s[p.pos+1]) && p.s[p.pos+1] != ')':
+		p.pos++
+		p.negated++
+		node, err := p.parseUnary()
+		p.negated--
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+
+	case p.s[p.pos] == '(':
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos == len(p.s) {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		if and, ok := node.(andNode); ok && len(and) == 0 {
+			return nil, errors.New("empty parentheses")
+		}
+		return node, nil
+	}
+	return p.parseTerm()
+}
+
+// parseTerm parses a word, phrase or regular expression, scoped to the
+// field before a colon if it names one. Other words with colons, such as
+// URLs, are searched for as they are.
+func (p *queryParser) parseTerm() (queryNode, error) {
+	start := p.pos
+	field := ""
+	if i := strings.IndexByte(p.s[p.pos:], ':'); i > 0 {
+		if name, ok := queryFields[strings.ToLower(p.s[p.pos:p.pos+i])]; ok {
+			field = name
+			p.pos += i + 1
+		}
+	}
+
+	if field == "created" || field == "updated" {
+		return p.parseDate(field)
+	}
+
+	term := termNode{field: field, exact: field == "tag" || field == "kind"}
+	switch {
+	case p.pos < len(p.s) && p.s[p.pos] == '"':
+		end := strings.IndexByte(p.s[p.pos+1:], '"')
+		if end < 0 {
+			return nil, errors.New("unclosed quote")
+		}
+		term.text = p.s[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+
+	case p.pos < len(p.s) && p.s[p.pos] == '/':
+		pattern, err := p.readRegexp()
+		if err != nil {
+			return nil, err
+		}
+		if term.re, err = regexp.Compile("(?i)" + pattern); err != nil {
+			return nil, fmt.Errorf("regular expression /%s/: %v", pattern, err)
+		}
+
+	default:
+		term.text = p.readWord()
+		term.fuzzy = field == "" || field == "title"
+	}
+
+	if term.re == nil && term.text == "" && field != "" {
+		return nil, fmt.Errorf("%s needs a value", p.s[start:p.pos])
+	}
+	if field == "tag" {
+		term.text = NormalizeTag(term.text)
+	}
+	term.text = strings.ToLower(term.text)
+	term.runes = []rune(term.text)
+	if term.re == nil && term.text != "" && !term.exact && p.negated == 0 {
+		p.words = append(p.words, term)
+	}
+	return term, nil
+}
+
+// readRegexp reads a regular expression between slashes, in which "\/"
+// stands for a slash
+func (p *queryParser) readRegexp() (string, error) {
+	var pattern strings.Builder
+	for i := p.pos + 1; i < len(p.s); i++ {
+		switch {
+		case p.s[i] == '\\' && i+1 < len(p.s) && p.s[i+1] == '/':
+			pattern.WriteByte('/')
+			i++
+		case p.s[i] == '/':
+			p.pos = i + 1
+			return pattern.String(), nil
+		default:
+			pattern.WriteByte(p.s[i])
+		}
+	}
+	return "", errors.New("unclosed regular expression")
+}
+
+// parseDate parses a date predicate after "created:" or "updated:": an
+// optional comparison, then an age such as 90d, 12h, 2w, 6m or 1y, or a
+// date as YYYY-MM-DD. Ages compare how long ago, so updated:<90d is the last
+// 90 days; dates compare when, so created:<2024-01-01 is before that day.
+// A date without a comparison matches that day.
+func (p *queryParser) parseDate(field string) (queryNode, error) {
+	word := p.readWord()
+	if word == "" {
+		return nil, fmt.Errorf("%s: needs a value", field)
+	}
+	op := strings.TrimLeft(word, "<>=")
+	op, value := word[:len(word)-len(op)], op
+	if op == "=" {
+		op = ""
+	}
+	node := dateNode{field: field}
+
+	if day, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case "":
+			node.from, node.to = day, next
+		case "<":
+			node.to = day
+		case "<=":
+			node.to = next
+		case ">":
+			node.from = next
+		case ">=":
+			node.from = day
+		default:
+			return nil, fmt.Errorf("%s:%s: unknown comparison %q", field, word, op)
+		}
+		return node, nil
+	}
+
+	ago, ok := p.ago(value)
+	if !ok {
+		return nil, fmt.Errorf("%s:%s: expected an age like 90d or a date like 2024-01-31", field, word)
+	}
+	switch op {
+	case "<", "<=":
+		node.from = ago
+	case ">", ">=":
+		node.to = ago
+	default:
+		return nil, fmt.Errorf("%s:%s: an age needs < or >, as in %s:<%s", field, word, field, value)
+	}
+	return node, nil
+}
+
+// ago converts an age such as 90d to the time that long before now. Units
+// are h(ours), d(ays), w(eeks), m(onths) and y(ears).
+func (p *queryParser) ago(age string) (time.Time, bool) {
+	if len(age) < 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(age[:len(age)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	switch age[len(age)-1] {
+	case 'h':
+		return p.now.Add(-time.Duration(n) * time.Hour), true
+	case 'd':
+		return p.now.AddDate(0, 0, -n), true
+	case 'w':
+		return p.now.AddDate(0, 0, -7*n), true
+	case 'm':
+		return p.now.AddDate(0, -n, 0), true
+	case 'y':
+		return p.now.AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// readWord reads up to the next space or closing parenthesis
+func (p *queryParser) readWord() string {
+	start := p.pos
+	for p.pos < len(p.s) && !isSpace(p.s[p.pos]) && p.s[p.pos] != ')' {
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// atOr reports whether the next word is the OR operator
+func (p *queryParser) atOr() bool {
+	rest := p.s[p.pos:]
+	return strings.HasPrefix(rest, "OR") && (len(rest) == 2 || isSpace(rest[2]) || rest[2] == '(' || rest[2] == ')')
+}
+
+// consumeOr skips an OR operator, reporting whether there was one
+func (p *queryParser) consumeOr() bool {
+	p.skipSpace()
+	if !p.atOr() {
+		return false
+	}
+	p.pos += len("OR")
+	return true
+}
+
+func (p *queryParser) skipSpace() {
+	for p.pos < len(p.s) && isSpace(p.s[p.pos]) {
+		p.pos++
+	}
+}
+
+func isSpace(b byte) bool {
+	return b < 0x80 && unicode.IsSpace(rune(b))
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.Local)
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.Local)
+	}
+	entries := []PasswordEntry{
+		{
+			Title: "GitHub", Username: "alice", URL: "https://github.com/login", Tags: []string{"work"},
+			CreatedAt: day(2024, 1, 1), UpdatedAt: now.AddDate(0, 0, -90),
+		},
+		{
+			Title: "Gmail", Username: "bob", URL: "https://mail.google.com", Tags: []string{"personal"},
+			CreatedAt: day(2024, 1, 1).Add(-time.Second), UpdatedAt: now.AddDate(0, 0, -90).Add(-time.Second),
+		},
+		{
+			Title: "Personal Router Old Device", Username: "admin", Notes: "rotated yearly", Tags: []string{"home"},
+			CreatedAt: day(2024, 1, 2), UpdatedAt: now,
+		},
+		{
+			Title: "prod db", Username: "postgres", URL: "https://db.example.com/a/b", Tags: []string{"prod"},
+			CreatedAt: day(2024, 3, 1), UpdatedAt: now.AddDate(-1, 0, 0),
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  []string // Titles matched, in entry order
+		err   bool
+	}{
+		{query: "", want: []string{"GitHub", "Gmail", "Personal Router Old Device", "prod db"}},
+		{query: "git", want: []string{"GitHub"}},
+		{query: "GIT alice", want: []string{"GitHub"}},
+		{query: "git bob"},
+
+		// Words match literally; fuzzy title matching only ranks
+		{query: "gthb"},
+		{query: "title:gl"},
+		{query: "-prod", want: []string{"GitHub", "Gmail", "Personal Router Old Device"}},
+		{query: "-title:gl", want: []string{"GitHub", "Gmail", "Personal Router Old Device", "prod db"}},
+
+		{query: "user:alice", want: []string{"GitHub"}},
+		{query: "username:ALICE", want: []string{"GitHub"}},
+		{query: "notes:yearly", want: []string{"Personal Router Old Device"}},
+		{query: "tag:prod", want: []string{"prod db"}},
+		{query: "tag:pro"},
+		{query: "kind:login", want: []string{"GitHub", "Gmail", "Personal Router Old Device", "prod db"}},
+		{query: `"prod db"`, want: []string{"prod db"}},
+		{query: `"db prod"`},
+		{query: "https://mail", want: []string{"Gmail"}},
+
+		// OR, negation and groups
+		{query: "github OR gmail", want: []string{"GitHub", "Gmail"}},
+		{query: "(github OR gmail) bob", want: []string{"Gmail"}},
+		{query: "-(github OR gmail)", want: []string{"Personal Router Old Device", "prod db"}},
+		{query: "--github", want: []string{"GitHub"}},
+		{query: "-(-github)", want: []string{"GitHub"}},
+		{query: "(github)OR(gmail)", want: []string{"GitHub", "Gmail"}},
+		{query: "ORACLE"},
+		{query: "(github", err: true},
+		{query: "github)", err: true},
+		{query: "()", err: true},
+		{query: "-(", err: true},
+		{query: "OR", err: true},
+		{query: "OR github", err: true},
+		{query: "github OR", err: true},
+		{query: "github OR OR gmail", err: true},
+		{query: "(OR github)", err: true},
+		{query: "(github OR)", err: true},
+
+		// Regular expressions, with \/ for a slash
+		{query: "/^git/", want: []string{"GitHub"}},
+		{query: `/com\/a\/b$/`, want: []string{"prod db"}},
+		{query: `url:/https:\/\/git/`, want: []string{"GitHub"}},
+		{query: `/a\/`, err: true},
+		{query: "/[/", err: true},
+
+		// Ages reach back from now, inclusive of the boundary
+		{query: "updated:<90d", want: []string{"GitHub", "Personal Router Old Device"}},
+		{query: "updated:<=90d", want: []string{"GitHub", "Personal Router Old Device"}},
+		{query: "updated:>90d", want: []string{"Gmail", "prod db"}},
+		{query: "updated:>1y"},
+		{query: "updated:>=1y"},
+		{query: "updated:<1h", want: []string{"Personal Router Old Device"}},
+		{query: "-updated:<90d", want: []string{"Gmail", "prod db"}},
+		{query: "updated:90d", err: true},
+		{query: "updated:<90x", err: true},
+		{query: "updated:<d", err: true},
+		{query: "updated:", err: true},
+
+		// A date alone is that whole day
+		{query: "created:2024-01-01", want: []string{"GitHub"}},
+		{query: "created:=2024-01-01", want: []string{"GitHub"}},
+		{query: "created:<2024-01-01", want: []string{"Gmail"}},
+		{query: "created:<=2024-01-01", want: []string{"GitHub", "Gmail"}},
+		{query: "created:>2024-01-01", want: []string{"Personal Router Old Device", "prod db"}},
+		{query: "created:>=2024-01-02", want: []string{"Personal Router Old Device", "prod db"}},
+		{query: "created:<<2024-01-01", err: true},
+		{query: "created:2024-13-01", err: true},
+
+		{query: `"unclosed`, err: true},
+		{query: "title:", err: true},
+		{query: `title:""`, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parseQuery(tt.query, now)
+			if tt.err {
+				if !errors.Is(err, ErrInvalidQuery) {
+					t.Fatalf("got %v, want ErrInvalidQuery", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for i := range entries {
+				if q.Match(newSearchText(&entries[i]), nil) {
+					got = append(got, entries[i].Title)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryWords(t *testing.T) {
+	q, err := ParseQuery(`github -gmail "prod db" tag:work /re/ (a OR -b)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for _, word := range q.words {
+		words = append(words, word.text)
+	}
+	// Negated terms, regular expressions and whole-value fields do not rank
+	if want := []string{"github", "prod db", "a"}; !slices.Equal(words, want) {
+		t.Errorf("ranking words %q, want %q", words, want)
+	}
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Usage records when entries were last used, by ID, so searches can rank
+// recently used entries higher
+type Usage map[string]time.Time
+
+// Used returns a copy of the usage noting that an entry was used at t. The
+// original is left alone for any search still reading it.
+func (u Usage) Used(id string, t time.Time) Usage {
+	used := maps.Clone(u)
+	if used == nil {
+		used = make(Usage)
+	}
+	used[id] = t
+	return used
+}
+
+// How much each field counts when a search word is found in it, so titles
+// rank above notes
+var fieldWeights = map[string]int{
+	"title": 400,
+	"user":  300,
+	"url":   250,
+	"tag":   250,
+	"field": 150,
+	"notes": 100,
+}
+
+const (
+	favoriteBoost  = 300
+	recentBoost    = 200                // For an entry used just now
+	recentHalfLife = 7 * 24 * time.Hour // The recent boost halves every week
+)
+
+// SearchResult is an entry found by a ranked search
+type SearchResult struct {
+	Entry  *PasswordEntry // Set by Vault.Search
+	Index  int            // Position of the entry's text among those searched
+	Score  int
+	Fields []string // Fields the search words were found in, best first
+	Title  []int    // Positions of the title's runes the words matched
+}
+
+// Ranked reports whether the query has words or phrases to rank matches by.
+// Queries of only field filters, such as tag:prod, do not.
+func (q Query) Ranked() bool {
+	return len(q.words) > 0
+}
+
+// Rank scores how well the entry with the search text matches the query's
+// words: by the fields they are found in, then how closely, with a boost
+// for favourites and entries used recently
+func (q Query) Rank(text *SearchText, usage Usage, now time.Time) SearchResult {
+	var result SearchResult
+	for _, word := range q.words {
+		points, field, title := word.rank(text)
+		result.Score += points
+		if field != "" && !slices.Contains(result.Fields, field) {
+			result.Fields = append(result.Fields, field)
+		}
+		result.Title = append(result.Title, title...)
+	}
+	if len(q.words) > 1 {
+		slices.SortStableFunc(result.Fields, func(a, b string) int {
+			return fieldWeights[b] - fieldWeights[a]
+		})
+		slices.Sort(result.Title)
+		result.Title = slices.Compact(result.Title)
+	}
+
+	if text.favorite {
+		result.Score += favoriteBoost
+	}
+	if used, ok := usage[text.id]; ok {
+		halvings := float64(now.Sub(used)) / float64(recentHalfLife)
+		result.Score += int(recentBoost * math.Pow(0.5, max(0, halvings)))
+	}
+	return result
+}
+
+// rank finds a word in the entry, returning the points it earns in the
+// field where it counts most, that field, and the title runes it matched
+func (n termNode) rank(text *SearchText) (int, string, []int) {
+	best, bestField := 0, ""
+	var title []int
+	if n.field == "" || n.field == "title" {
+		if points, positions, ok := titleMatch(n, text); ok {
+			best, bestField, title = points, "title", positions
+		}
+	}
+
+	for _, field := range []string{"user", "url", "tag", "field", "notes"} {
+		if n.field != "" && n.field != field {
+			continue
+		}
+		for _, value := range text.valuesOf(field, nil) {
+			if !strings.Contains(value, n.text) {
+				continue
+			}
+			points := fieldWeights[field]
+			if value == n.text {
+				points += fieldWeights[field] / 2
+			}
+			if points > best {
+				best, bestField = points, field
+			}
+		}
+	}
+	return best, bestField, title
+}
+
+// titleMatch scores a word or phrase found in a title. A whole title beats
+// a prefix, which beats the start of a word, then anywhere; failing those,
+// a word can match with its letters spread out. It returns the points and
+// the positions of the runes matched.
+func titleMatch(n termNode, text *SearchText) (int, []int, bool) {
+	if i := runeIndex(text.lowerTitle, n.runes); i >= 0 {
+		points := fieldWeights["title"]
+		switch {
+		case len(text.lowerTitle) == len(n.runes):
+			points += 600
+		case i == 0:
+			points += 300
+		case wordStart(text.title, i):
+			points += 150
+		}
+		positions := make([]int, len(n.runes))
+		for j := range positions {
+			positions[j] = i + j
+		}
+		return points, positions, true
+	}
+
+	if !n.fuzzy {
+		return 0, nil, false
+	}
+	return fuzzyMatch(n.runes, text)
+}
+
+// fuzzyMatch looks for the wanted lower-case runes in order in the title.
+// Each is taken right after the previous one, or else at the start of a
+// word, where it can be. It returns points for how closely the runes are
+// grouped and whether they start the title, at most half what finding the
+// word whole in a title earns, and their positions.
+func fuzzyMatch(wanted []rune, text *SearchText) (int, []int, bool) {
+	if len(wanted) == 0 {
+		return 0, nil, false
+	}
+
+	original := text.title
+	positions, ok := fuzzyPositions(wanted, text.lowerTitle, original, true)
+	if !ok {
+		// Preferring word starts can skip past what later runes need
+		if positions, ok = fuzzyPositions(wanted, text.lowerTitle, original, false); !ok {
+			return 0, nil, false
+		}
+	}
+
+	points := 0
+	for i, pos := range positions {
+		switch {
+		case i > 0 && pos == positions[i-1]+1:
+			points += 8
+		case wordStart(original, pos):
+			points += 6
+		default:
+			points += 1
+		}
+	}
+	points = points*100/(8*len(wanted)) - (positions[len(positions)-1] - positions[0] + 1 - len(wanted))
+	if positions[0] == 0 {
+		points += 25
+	}
+	return max(10, min(points, fieldWeights["title"]/2)), positions, true
+}
+
+// fuzzyPositions finds each wanted rune in order, optionally preferring
+// word starts to the first occurrence
+func fuzzyPositions(wanted, runes, original []rune, preferWordStarts bool) ([]int, bool) {
+	positions := make([]int, 0, len(wanted))
+	next := 0
+	for _, r := range wanted {
+		found := -1
+		for i := next; i < len(runes); i++ {
+			if runes[i] != r {
+				continue
+			}
+			if found < 0 {
+				found = i
+			}
+			if !preferWordStarts || (len(positions) > 0 && i == next) || wordStart(original, i) {
+				found = i
+				break
+			}
+		}
+		if found < 0 {
+			return nil, false
+		}
+		positions = append(positions, found)
+		next = found + 1
+	}
+	return positions, true
+}
+
+// wordStart reports whether the rune at i begins a word, including the
+// upper-case start of a word inside camelCase
+func wordStart(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, r := runes[i-1], runes[i]
+	return !unicode.IsLetter(prev) && !unicode.IsDigit(prev) ||
+		unicode.IsLower(prev) && unicode.IsUpper(r)
+}
+
+// lowerRunes lower-cases s rune by rune, so positions stay those of s
+func lowerRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// runeIndex returns where needle first appears in haystack, or -1
+func runeIndex(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if slices.Equal(haystack[i:i+len(needle)], needle) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Search returns the results for the search texts matching the query, by
+// their index in texts. Queries with words are ranked best first; others
+// keep the order of texts.
+func (q Query) Search(texts []*SearchText, folders []Folder, usage Usage) []SearchResult {
+	now := time.Now()
+	var results []SearchResult
+	for i, text := range texts {
+		if q.Match(text, folders) {
+			result := q.Rank(text, usage, now)
+			result.Index = i
+			results = append(results, result)
+		}
+	}
+	if q.Ranked() {
+		// Ties keep their order, as a stable sort would but faster
+		slices.SortFunc(results, func(a, b SearchResult) int {
+			return cmp.Or(b.Score-a.Score, a.Index-b.Index)
+		})
+	}
+	return results
+}
+
+// Search returns the entries matching the query, ranked like Query.Search
+func (v *Vault) Search(query Query, usage Usage) []SearchResult {
+	texts := make([]*SearchText, len(v.Entries))
+	for i := range v.Entries {
+		texts[i] = v.SearchText(&v.Entries[i])
+	}
+	results := query.Search(texts, v.Folders, usage)
+	for i := range results {
+		results[i].Entry = &v.Entries[results[i].Index]
+	}
+	return results
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// NormalizeTag lower-cases a tag and strips a leading '#'
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
+
+// ParseTags splits a list of tags separated by commas or spaces
+func ParseTags(s string) []string {
+	return NormalizeTags(strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	}))
+}
+
+// NormalizeTags normalizes each tag, dropping empty and repeated ones, and
+// sorts the result
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return normalized
+}
+
+// HasTag reports whether the entry carries tag
+func (p *PasswordEntry) HasTag(tag string) bool {
+	return slices.Contains(p.Tags, NormalizeTag(tag))
+}
+
+// Tags returns every tag used in the vault, sorted
+func (v *Vault) Tags() []string {
+	var tags []string
+	for _, entry := range v.Entries {
+		for _, tag := range entry.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
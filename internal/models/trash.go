@@ -0,0 +1,172 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TrashedEntry is a deleted entry kept until it is restored or purged
+type TrashedEntry struct {
+	PasswordEntry
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// PurgesAt returns when the entry is purged given how long the trash keeps
+// entries; the zero time if it is kept until emptied
+func (t *TrashedEntry) PurgesAt(retention time.Duration) time.Time {
+	if retention <= 0 {
+		return time.Time{}
+	}
+	return t.DeletedAt.Add(retention)
+}
+
+// trashEntry moves the entry at position i to the trash, deleted at deletedAt
+func (v *Vault) trashEntry(i int, deletedAt time.Time) {
+	entry := v.removeEntry(i)
+	v.Trash = append(v.Trash, TrashedEntry{PasswordEntry: entry, DeletedAt: deletedAt})
+}
+
+// GetTrashed retrieves a deleted entry by ID
+func (v *Vault) GetTrashed(id string) (*TrashedEntry, bool) {
+	for i := range v.Trash {
+		if v.Trash[i].ID == id {
+			return &v.Trash[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindTrashed resolves ref to a single deleted entry by ID, then exact
+// title (case-insensitive), then unique title substring
+func (v *Vault) FindTrashed(ref string) (*TrashedEntry, error) {
+	if trashed, ok := v.GetTrashed(ref); ok {
+		return trashed, nil
+	}
+
+	lower := strings.ToLower(ref)
+	var exact, partial []int
+	for i, trashed := range v.Trash {
+		title := strings.ToLower(trashed.Title)
+		if title == lower {
+			exact = append(exact, i)
+		} else if strings.Contains(title, lower) {
+			partial = append(partial, i)
+		}
+	}
+
+	candidates := exact
+	if len(candidates) == 0 {
+		candidates = partial
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil, fmt.Errorf("%w in trash: %s", ErrNotFound, ref)
+	case 1:
+		return &v.Trash[candidates[0]], nil
+	default:
+		var names []string
+		for _, i := range candidates {
+			names = append(names, fmt.Sprintf("%s (%s)", v.Trash[i].Title, v.Trash[i].ID))
+		}
+		return nil, fmt.Errorf("%w: %q matches %s in trash", ErrAmbiguous, ref, strings.Join(names, ", "))
+	}
+}
+
+// RestoreEntry moves a deleted entry back out of the trash. It returns to
+// the top level if its folder has been deleted since.
+func (v *Vault) RestoreEntry(id string) (*PasswordEntry, error) {
+	for i, trashed := range v.Trash {
+		if trashed.ID != id {
+			continue
+		}
+		entry := trashed.PasswordEntry
+		if _, ok := v.GetFolder(entry.FolderID); !ok {
+			entry.FolderID = ""
+		}
+		v.Trash = slices.Delete(v.Trash, i, i+1)
+		return v.appendEntry(entry), nil
+	}
+	return nil, fmt.Errorf("%w in trash: %s", ErrNotFound, id)
+}
+
+// PurgeEntry deletes an entry in the trash for good
+func (v *Vault) PurgeEntry(id string) bool {
+	for i, trashed := range v.Trash {
+		if trashed.ID == id {
+			v.Trash = slices.Delete(v.Trash, i, i+1)
+			return true
+		}
+	}
+	return false
+}
+
+// EmptyTrash deletes every entry in the trash for good, returning how many
+// there were
+func (v *Vault) EmptyTrash() int {
+	n := len(v.Trash)
+	v.Trash = nil
+	return n
+}
+
+// PurgeTrash deletes the entries that have been in the trash for longer
+// than retention, returning how many. A retention of 0 keeps them all.
+func (v *Vault) PurgeTrash(retention time.Duration, now time.Time) int {
+	if retention <= 0 {
+		return 0
+	}
+	n := len(v.Trash)
+	v.Trash = slices.DeleteFunc(v.Trash, func(trashed TrashedEntry) bool {
+		return now.After(trashed.PurgesAt(retention))
+	})
+	if len(v.Trash) == 0 {
+		v.Trash = nil
+	}
+	return n - len(v.Trash)
+}
+
+// MergeTrash performs a three-way merge of the trash keyed on ID, like
+// MergeFolders: an entry missing on one side survives only if it was
+// trashed after the base. Entries also in the merged vault, whose deletion
+// lost a merge conflict, are dropped from the trash.
+func MergeTrash(base, local, remote []TrashedEntry, entries []PasswordEntry) []TrashedEntry {
+	live := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		live[entry.ID] = true
+	}
+	baseByID := make(map[string]TrashedEntry, len(base))
+	for _, trashed := range base {
+		baseByID[trashed.ID] = trashed
+	}
+	keep := func(trashed TrashedEntry) bool {
+		b, inBase := baseByID[trashed.ID]
+		return !inBase || !trashed.DeletedAt.Equal(b.DeletedAt)
+	}
+
+	remoteByID := make(map[string]TrashedEntry, len(remote))
+	for _, trashed := range remote {
+		remoteByID[trashed.ID] = trashed
+	}
+
+	var merged []TrashedEntry
+	localIDs := make(map[string]bool, len(local))
+	for _, l := range local {
+		localIDs[l.ID] = true
+		r, inRemote := remoteByID[l.ID]
+		switch {
+		case live[l.ID]:
+		case inRemote && r.DeletedAt.After(l.DeletedAt):
+			merged = append(merged, r)
+		case inRemote || keep(l):
+			merged = append(merged, l)
+		}
+	}
+	for _, r := range remote {
+		if !localIDs[r.ID] && !live[r.ID] && keep(r) {
+			merged = append(merged, r)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,249 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One-time password keys as used by authenticator apps: HOTP (RFC 4226) and
+// TOTP (RFC 6238). A key is stored either as an otpauth:// URI, in the Key
+// Uri Format popularised by Google Authenticator, or as a bare base32 seed
+// meaning a TOTP key with the default settings.
+
+const (
+	TypeTOTP = "totp"
+	TypeHOTP = "hotp"
+
+	AlgorithmSHA1   = "SHA1"
+	AlgorithmSHA256 = "SHA256"
+	AlgorithmSHA512 = "SHA512"
+
+	DefaultDigits = 6
+	DefaultPeriod = 30 // Seconds
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid one-time password key")
+	ErrNotTOTP    = errors.New("key is not time-based")
+	ErrNotHOTP    = errors.New("key is not counter-based")
+)
+
+// Key holds everything needed to generate codes
+type Key struct {
+	Type      string
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    int    // TOTP step in seconds
+	Counter   uint64 // HOTP counter of the next code
+	Issuer    string
+	Account   string
+}
+
+// Parse reads an otpauth:// URI or a base32 seed
+func Parse(value string) (*Key, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalidKey)
+	}
+	if !strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		secret, err := decodeSecret(value)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{
+			Type:      TypeTOTP,
+			Secret:    secret,
+			Algorithm: AlgorithmSHA1,
+			Digits:    DefaultDigits,
+			Period:    DefaultPeriod,
+		}, nil
+	}
+	return parseURI(value)
+}
+
+func parseURI(value string) (*Key, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	key := &Key{
+		Type:      strings.ToLower(u.Host),
+		Algorithm: AlgorithmSHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+	if key.Type != TypeTOTP && key.Type != TypeHOTP {
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidKey, u.Host)
+	}
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, ok := strings.Cut(label, ":"); ok {
+		key.Issuer = strings.TrimSpace(issuer)
+		key.Account = strings.TrimSpace(account)
+	} else {
+		key.Account = strings.TrimSpace(label)
+	}
+
+	query := u.Query()
+	if key.Secret, err = decodeSecret(query.Get("secret")); err != nil {
+		return nil, err
+	}
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if _, err := key.hash(); err != nil {
+			return nil, err
+		}
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil || key.Digits < 6 || key.Digits > 10 {
+			return nil, fmt.Errorf("%w: digits must be 6 to 10", ErrInvalidKey)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil || key.Period <= 0 {
+			return nil, fmt.Errorf("%w: bad period %q", ErrInvalidKey, period)
+		}
+	}
+
+	if key.Type == TypeHOTP {
+		counter := query.Get("counter")
+		if counter == "" {
+			return nil, fmt.Errorf("%w: hotp key needs a counter", ErrInvalidKey)
+		}
+		if key.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: bad counter %q", ErrInvalidKey, counter)
+		}
+	}
+
+	return key, nil
+}
+
+// decodeSecret decodes a base32 seed, tolerating spaces, lower case and
+// missing padding as shown by most services
+func decodeSecret(secret string) ([]byte, error) {
+	cleaned := strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(secret))
+	cleaned = strings.TrimRight(cleaned, "=")
+	if cleaned == "" {
+		return nil, fmt.Errorf("%w: missing secret", ErrInvalidKey)
+	}
+
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret is not base32", ErrInvalidKey)
+	}
+	return decoded, nil
+}
+
+// URI returns the key in otpauth:// form
+func (k *Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	query := url.Values{}
+	query.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Secret))
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	if k.Algorithm != AlgorithmSHA1 {
+		query.Set("algorithm", k.Algorithm)
+	}
+	if k.Digits != DefaultDigits {
+		query.Set("digits", strconv.Itoa(k.Digits))
+	}
+	if k.Type == TypeTOTP && k.Period != DefaultPeriod {
+		query.Set("period", strconv.Itoa(k.Period))
+	}
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: k.Type, Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// TOTP returns the time-based code at t
+func (k *Key) TOTP(t time.Time) (string, error) {
+	if k.Type != TypeTOTP {
+		return "", ErrNotTOTP
+	}
+	return k.generate(uint64(t.Unix()) / uint64(k.Period))
+}
+
+// Remaining returns how long the TOTP code at t stays valid
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := time.Duration(k.Period) * time.Second
+	return period - time.Duration(t.UnixNano())%period
+}
+
+// NextHOTP returns the code for the current counter and advances it. The
+// caller must save the key afterwards so a code is never issued twice.
+func (k *Key) NextHOTP() (string, error) {
+	if k.Type != TypeHOTP {
+		return "", ErrNotHOTP
+	}
+	code, err := k.generate(k.Counter)
+	if err != nil {
+		return "", err
+	}
+	k.Counter++
+	return code, nil
+}
+
+// generate computes the RFC 4226 code for a moving factor
+func (k *Key) generate(factor uint64) (string, error) {
+	newHash, err := k.hash()
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], factor)
+	mac := hmac.New(newHash, k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint64(1)
+	for i := 0; i < k.Digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, uint64(value)%modulus), nil
+}
+
+func (k *Key) hash() (func() hash.Hash, error) {
+	switch k.Algorithm {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidKey, k.Algorithm)
+	}
+}
@@ -0,0 +1,129 @@
+package otp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// RFC 4226 Appendix D
+func TestHOTPVectors(t *testing.T) {
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	key := &Key{Type: TypeHOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 6}
+	for counter, code := range want {
+		got, err := key.NextHOTP()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+	if key.Counter != uint64(len(want)) {
+		t.Errorf("counter is %d after %d codes", key.Counter, len(want))
+	}
+}
+
+// RFC 6238 Appendix B
+func TestTOTPVectors(t *testing.T) {
+	secrets := map[string]string{
+		AlgorithmSHA1:   "12345678901234567890",
+		AlgorithmSHA256: "12345678901234567890123456789012",
+		AlgorithmSHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	tests := []struct {
+		unix int64
+		want map[string]string
+	}{
+		{59, map[string]string{AlgorithmSHA1: "94287082", AlgorithmSHA256: "46119246", AlgorithmSHA512: "90693936"}},
+		{1111111109, map[string]string{AlgorithmSHA1: "07081804", AlgorithmSHA256: "68084774", AlgorithmSHA512: "25091201"}},
+		{1111111111, map[string]string{AlgorithmSHA1: "14050471", AlgorithmSHA256: "67062674", AlgorithmSHA512: "99943326"}},
+		{1234567890, map[string]string{AlgorithmSHA1: "89005924", AlgorithmSHA256: "91819424", AlgorithmSHA512: "93441116"}},
+		{2000000000, map[string]string{AlgorithmSHA1: "69279037", AlgorithmSHA256: "90698825", AlgorithmSHA512: "38618901"}},
+		{20000000000, map[string]string{AlgorithmSHA1: "65353130", AlgorithmSHA256: "77737706", AlgorithmSHA512: "47863826"}},
+	}
+	for _, tt := range tests {
+		for algorithm, want := range tt.want {
+			key := &Key{Type: TypeTOTP, Secret: []byte(secrets[algorithm]), Algorithm: algorithm, Digits: 8, Period: DefaultPeriod}
+			got, err := key.TOTP(time.Unix(tt.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s at %d: got %s, want %s", algorithm, tt.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestParseSeed(t *testing.T) {
+	key, err := Parse(" gezd gnbv gy3t qojq-gezd gnbv gy3t qojq== ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Key{Type: TypeTOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}
+	if !reflect.DeepEqual(key, want) {
+		t.Errorf("got %+v, want %+v", key, want)
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	key, err := Parse("otpauth://totp/ACME%20Co:john.doe@email.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=sha256&digits=8&period=60")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Type != TypeTOTP || key.Issuer != "ACME Co" || key.Account != "john.doe@email.com" ||
+		key.Algorithm != AlgorithmSHA256 || key.Digits != 8 || key.Period != 60 || len(key.Secret) != 20 {
+		t.Errorf("parsed %+v", key)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"not base32!",
+		"otpauth://sotp/x?secret=GEZDGNBV",
+		"otpauth://totp/x",
+		"otpauth://totp/x?secret=GEZDGNBV&algorithm=md5",
+		"otpauth://totp/x?secret=GEZDGNBV&digits=5",
+		"otpauth://totp/x?secret=GEZDGNBV&digits=11",
+		"otpauth://totp/x?secret=GEZDGNBV&period=0",
+		"otpauth://hotp/x?secret=GEZDGNBV",
+		"otpauth://hotp/x?secret=GEZDGNBV&counter=-1",
+	} {
+		if _, err := Parse(value); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Parse(%q): got %v, want ErrInvalidKey", value, err)
+		}
+	}
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	for _, key := range []*Key{
+		{Type: TypeTOTP, Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod, Account: "alice"},
+		{Type: TypeTOTP, Secret: secret, Algorithm: AlgorithmSHA512, Digits: 8, Period: 60, Issuer: "ACME Co", Account: "alice@example.com"},
+		{Type: TypeHOTP, Secret: secret, Algorithm: AlgorithmSHA256, Digits: 7, Period: DefaultPeriod, Counter: 42, Issuer: "Example", Account: "bob"},
+		{Type: TypeHOTP, Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod, Counter: 0, Account: "carol"},
+	} {
+		uri := key.URI()
+		parsed, err := Parse(uri)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", uri, err)
+		}
+		if !reflect.DeepEqual(parsed, key) {
+			t.Errorf("%s: got %+v, want %+v", uri, parsed, key)
+		}
+	}
+}
+
+func TestWrongType(t *testing.T) {
+	totp := &Key{Type: TypeTOTP, Secret: []byte("x"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30}
+	if _, err := totp.NextHOTP(); !errors.Is(err, ErrNotHOTP) {
+		t.Errorf("NextHOTP on a TOTP key: got %v", err)
+	}
+	hotp := &Key{Type: TypeHOTP, Secret: []byte("x"), Algorithm: AlgorithmSHA1, Digits: 6}
+	if _, err := hotp.TOTP(time.Now()); !errors.Is(err, ErrNotTOTP) {
+		t.Errorf("TOTP on a HOTP key: got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SyncError reports that a file was replaced but its directory could not be
+// synced afterwards. The new file is in place and is what is read from now
+// on; only its surviving a crash is in doubt, so callers treat the write as
+// done.
+type SyncError struct {
+	Path string
+	Err  error
+}
+
+func (e *SyncError) Error() string {
+	return fmt.Sprintf("%s was written but may not survive a crash: %v", filepath.Base(e.Path), e.Err)
+}
+
+func (e *SyncError) Unwrap() error {
+	return e.Err
+}
+
+// writeFileAtomic replaces path with data so that a crash, full disk or kill
+// at any point leaves either the old file or the complete new one:
+//
+//  1. write to a temporary file in the same directory
+//  2. fsync it, then run verify (if any) against the temporary file
+//  3. rename it over path
+//  4. fsync the directory so the rename itself is durable
+//
+// The temporary file is removed on any failure before the rename. A failure
+// to sync the directory after it is reported as a *SyncError.
+func writeFileAtomic(path string, data []byte, perm os.FileMode, verify func(tmpPath string) error) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	renamed := false
+	defer func() {
+		if !renamed {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if err := tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if verify != nil {
+		if err := verify(tmpPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+	renamed = true
+
+	if err := syncDir(dir); err != nil {
+		return &SyncError{Path: path, Err: err}
+	}
+	return nil
+}
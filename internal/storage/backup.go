@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"vault/internal/crypto"
+)
+
+const (
+	BackupDirName   = "backups"
+	backupExt       = ".enc"
+	backupTimestamp = "20060102T150405.000Z" // UTC, sorts lexically
+	backupSeqSep    = "-"                    // Before the number telling apart backups made the same millisecond
+)
+
+var ErrBackupNotFound = errors.New("backup not found")
+
+// BackupPolicy controls the encrypted copies kept before each save
+type BackupPolicy struct {
+	Count  int           // Number of backups to keep; 0 disables backups
+	MaxAge time.Duration // Remove backups older than this; 0 keeps them regardless of age
+}
+
+// DefaultBackupPolicy returns the retention used when none is configured
+func DefaultBackupPolicy() BackupPolicy {
+	return BackupPolicy{Count: 10, MaxAge: 90 * 24 * time.Hour}
+}
+
+// Backup describes one backup generation of the vault file
+type Backup struct {
+	Name      string
+	Path      string
+	CreatedAt time.Time
+	Size      int64
+
+	seq int // Order among backups with the same CreatedAt
+}
+
+// SetBackupPolicy sets how many backups are kept and for how long
+func (s *Storage) SetBackupPolicy(policy BackupPolicy) {
+	s.backups = policy
+}
+
+// BackupDir returns the directory holding vault backups
+func (s *Storage) BackupDir() string {
+	return filepath.Join(filepath.Dir(s.filePath), BackupDirName)
+}
+
+// backupPrefix is the file name prefix shared by this vault's backups
+func (s *Storage) backupPrefix() string {
+	base := filepath.Base(s.filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + "-"
+}
+
+// ListBackups returns the vault's backups, newest first
+func (s *Storage) ListBackups() ([]Backup, error) {
+	entries, err := os.ReadDir(s.BackupDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	prefix := s.backupPrefix()
+	var backups []Backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, backupExt) {
+			continue
+		}
+
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), backupExt)
+		stamp, suffix, hasSeq := strings.Cut(stamp, backupSeqSep)
+		createdAt, err := time.Parse(backupTimestamp, stamp)
+		if err != nil {
+			continue
+		}
+		seq := 0
+		if hasSeq {
+			if seq, err = strconv.Atoi(suffix); err != nil || seq < 1 {
+				continue
+			}
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Name:      name,
+			Path:      filepath.Join(s.BackupDir(), name),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+			seq:       seq,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		if backups[i].CreatedAt.Equal(backups[j].CreatedAt) {
+			return backups[i].seq > backups[j].seq
+		}
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// backupCurrent copies the vault file as it is now into the backup directory
+// and applies the retention policy. It does nothing if backups are disabled
+// or there is no vault file yet.
+func (s *Storage) backupCurrent() error {
+	if s.backups.Count <= 0 || !s.VaultExists() {
+		return nil
+	}
+
+	if err := os.MkdirAll(s.BackupDir(), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read vault file: %w", err)
+	}
+
+	path, err := s.newBackupPath(time.Now())
+	if err != nil {
+		return err
+	}
+	var syncErr *SyncError
+	if err := writeFileAtomic(path, data, VaultPermissions, nil); err != nil && !errors.As(err, &syncErr) {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	return s.pruneBackups()
+}
+
+// newBackupPath names a backup made at now. Saves made within the same
+// millisecond get a numbered suffix rather than replacing each other; the
+// vault lock keeps another process from taking the same name meanwhile.
+func (s *Storage) newBackupPath(now time.Time) (string, error) {
+	base := s.backupPrefix() + now.UTC().Format(backupTimestamp)
+	for seq := 0; ; seq++ {
+		name := base + backupExt
+		if seq > 0 {
+			name = base + backupSeqSep + strconv.Itoa(seq) + backupExt
+		}
+		path := filepath.Join(s.BackupDir(), name)
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			return path, nil
+		} else if err != nil {
+			return "", fmt.Errorf("failed to check backup name: %w", err)
+		}
+	}
+}
+
+// pruneBackups removes backups beyond the retention count or age. The newest
+// backup is always kept.
+func (s *Storage) pruneBackups() error {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i, backup := range backups {
+		if i == 0 {
+			continue
+		}
+		tooMany := i >= s.backups.Count
+		tooOld := s.backups.MaxAge > 0 && now.Sub(backup.CreatedAt) > s.backups.MaxAge
+		if tooMany || tooOld {
+			if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old backup: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// FindBackup looks a backup up by name or by 1-based position in ListBackups
+func (s *Storage) FindBackup(ref string) (*Backup, error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	if index, err := strconv.Atoi(ref); err == nil {
+		if index >= 1 && index <= len(backups) {
+			return &backups[index-1], nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, ref)
+	}
+
+	for i := range backups {
+		if backups[i].Name == ref {
+			return &backups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBackupNotFound, ref)
+}
+
+// RekeyBackups re-encrypts the backups oldPassword opens under the session
+// of a new master password, and deletes those it does not open, which were
+// made under still older passwords. No backup is then left that a retired
+// password opens. It returns how many backups were re-encrypted and deleted.
+func (s *Storage) RekeyBackups(oldPassword string, session *crypto.Session) (rekeyed, deleted int, err error) {
+	err = s.withLock(func() error {
+		rekeyed, deleted, err = s.rekeyBackups(oldPassword, session)
+		return err
+	})
+	return rekeyed, deleted, err
+}
+
+func (s *Storage) rekeyBackups(oldPassword string, session *crypto.Session) (rekeyed, deleted int, err error) {
+	backups, err := s.ListBackups()
+	if err != nil {
+		return 0, 0, err
+	}
+	newKey, err := session.Key()
+	if err != nil {
+		return 0, 0, err
+	}
+	header, err := encodeHeader(FileHeader{
+		KDF:    session.KDFParams(),
+		Cipher: crypto.CipherAES256GCM,
+		Salt:   session.Salt(),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Backups of the same generation share a salt, so derive each old key once
+	type keyParams struct {
+		salt string
+		kdf  crypto.KDFParams
+	}
+	oldKeys := make(map[keyParams]*crypto.Session)
+	defer func() {
+		for _, old := range oldKeys {
+			old.Wipe()
+		}
+	}()
+
+	for _, backup := range backups {
+		data, err := os.ReadFile(backup.Path)
+		if err != nil {
+			return rekeyed, deleted, fmt.Errorf("failed to read backup %s: %w", backup.Name, err)
+		}
+
+		var plaintext []byte
+		if file, err := parseVaultFile(data); err == nil {
+			params := keyParams{string(file.Header.Salt), file.Header.KDF}
+			old, ok := oldKeys[params]
+			if !ok {
+				if old, err = crypto.NewSession(oldPassword, file.Header.Salt, file.Header.KDF); err != nil {
+					return rekeyed, deleted, fmt.Errorf("failed to derive key for backup %s: %w", backup.Name, err)
+				}
+				oldKeys[params] = old
+			}
+			if key, err := old.Key(); err == nil {
+				plaintext, _ = crypto.DecryptWithAAD(file.Payload, key, file.AAD)
+			}
+		}
+
+		if plaintext == nil {
+			if err := os.Remove(backup.Path); err != nil {
+				return rekeyed, deleted, fmt.Errorf("failed to delete backup %s: %w", backup.Name, err)
+			}
+			deleted++
+			continue
+		}
+
+		err = s.rewriteBackup(backup.Path, header, newKey, plaintext)
+		crypto.SecureWipe(plaintext)
+		if err != nil {
+			return rekeyed, deleted, fmt.Errorf("failed to re-encrypt backup %s: %w", backup.Name, err)
+		}
+		rekeyed++
+	}
+	return rekeyed, deleted, nil
+}
+
+// rewriteBackup replaces the backup at path with plaintext encrypted under key
+func (s *Storage) rewriteBackup(path string, header, key, plaintext []byte) error {
+	encrypted, err := crypto.EncryptWithAAD(plaintext, key, header)
+	if err != nil {
+		return err
+	}
+	fileData := append(append([]byte(nil), header...), encrypted...)
+
+	verify := func(tmpPath string) error {
+		return verifyVaultFile(tmpPath, key, plaintext)
+	}
+	var syncErr *SyncError
+	if err := writeFileAtomic(path, fileData, VaultPermissions, verify); err != nil && !errors.As(err, &syncErr) {
+		return err
+	}
+	return nil
+}
+
+// RestoreBackup replaces the vault file with the given backup. The current
+// vault is backed up first so the restore itself can be rolled back. A
+// *SyncError means the backup was restored but may not survive a crash.
+func (s *Storage) RestoreBackup(backup Backup) error {
+	return s.withLock(func() error {
+		return s.restoreBackup(backup)
+	})
+}
+
+func (s *Storage) restoreBackup(backup Backup) error {
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+
+	if _, err := parseVaultFile(data); err != nil {
+		return fmt.Errorf("backup %s is not a valid vault file: %w", backup.Name, err)
+	}
+
+	if err := s.EnsureVaultDir(); err != nil {
+		return err
+	}
+	if err := s.backupCurrent(); err != nil {
+		return err
+	}
+
+	err = writeFileAtomic(s.filePath, data, VaultPermissions, nil)
+	var syncErr *SyncError
+	if err != nil && !errors.As(err, &syncErr) {
+		return fmt.Errorf("failed to restore backup: %w", err)
+	}
+	return err // Nil, or a *SyncError for a backup that was still restored
+}
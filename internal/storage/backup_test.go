@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"vault/internal/models"
+)
+
+func TestBackupsInTheSameMillisecond(t *testing.T) {
+	s := NewStorage(filepath.Join(t.TempDir(), "vault.enc"))
+	if err := os.MkdirAll(s.BackupDir(), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var names []string
+	for i := range 3 {
+		path, err := s.newBackupPath(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte{byte(i)}, VaultPermissions); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{"vault-20240501T120000.000Z.enc", "vault-20240501T120000.000Z-1.enc", "vault-20240501T120000.000Z-2.enc"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("named %q, want %q", names, want)
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed []string
+	for _, backup := range backups {
+		listed = append(listed, backup.Name)
+		if !backup.CreatedAt.Equal(now) {
+			t.Errorf("%s created at %s, want %s", backup.Name, backup.CreatedAt, now)
+		}
+	}
+	slices.Reverse(want) // Newest first
+	if !slices.Equal(listed, want) {
+		t.Errorf("listed %q, want %q", listed, want)
+	}
+}
+
+func TestRekeyBackups(t *testing.T) {
+	const newPassword = "new master password"
+	s := newTestStorage(t)
+	vault, session, err := s.CreateNewVault(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := models.NewPasswordEntry(models.EntryFields{Title: "github", Password: "p"})
+	vault.AddEntry(entry)
+	if err := s.SaveVault(vault, session); err != nil {
+		t.Fatal(err)
+	}
+	session.Wipe()
+
+	// A backup from before the current password
+	older := newTestStorage(t)
+	_, olderSession, err := older.CreateNewVault("an earlier password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	olderSession.Wipe()
+	data, err := os.ReadFile(older.GetVaultPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	olderPath, err := s.newBackupPath(time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(olderPath, data, VaultPermissions); err != nil {
+		t.Fatal(err)
+	}
+
+	_, session, err = s.ChangeMasterPassword(testPassword, newPassword, s.kdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Wipe()
+	rekeyed, deleted, err := s.RekeyBackups(testPassword, session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rekeyed != 2 || deleted != 1 {
+		t.Errorf("re-encrypted %d and deleted %d backups, want 2 and 1", rekeyed, deleted)
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != rekeyed {
+		t.Fatalf("%d backups left, want %d", len(backups), rekeyed)
+	}
+	for i, backup := range backups {
+		if backup.Path == olderPath {
+			t.Errorf("%s from an earlier password was kept", backup.Name)
+		}
+		if _, _, err := NewStorage(backup.Path).LoadVault(testPassword); !errors.Is(err, ErrWrongPassword) {
+			t.Errorf("%s with the old password: got %v, want %v", backup.Name, err, ErrWrongPassword)
+		}
+		restored, restoredSession, err := NewStorage(backup.Path).LoadVault(newPassword)
+		if err != nil {
+			t.Errorf("%s with the new password: %v", backup.Name, err)
+			continue
+		}
+		restoredSession.Wipe()
+		if _, ok := restored.GetEntry(entry.ID); i == 0 && !ok { // Made by the password change
+			t.Errorf("%s lost entry %s", backup.Name, entry.Title)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package storage_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"vault/internal/crypto"
+	"vault/internal/models"
+	"vault/internal/models/modelstest"
+	"vault/internal/storage"
+)
+
+const benchPassword = "correct horse battery staple"
+
+// benchVault saves a vault of generated entries in a temporary directory
+func benchVault(b *testing.B) (*storage.Storage, *models.Vault, *crypto.Session) {
+	b.Helper()
+	store := storage.NewStorage(filepath.Join(b.TempDir(), "vault.enc"))
+	_, session, err := store.CreateNewVault(benchPassword)
+	if err != nil {
+		b.Fatal(err)
+	}
+	vault := modelstest.BenchVault()
+	if err := store.SaveVault(vault, session); err != nil {
+		b.Fatal(err)
+	}
+	return store, vault, session
+}
+
+// BenchmarkLoadVault unlocks a large vault: key derivation, decrypting and
+// decoding
+func BenchmarkLoadVault(b *testing.B) {
+	store, _, _ := benchVault(b)
+	b.ResetTimer()
+	for range b.N {
+		if _, _, err := storage.NewStorage(store.GetVaultPath()).LoadVault(benchPassword); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkSaveVault saves an edit to a large vault, backup included
+func BenchmarkSaveVault(b *testing.B) {
+	store, vault, session := benchVault(b)
+	entry := vault.Entries[len(vault.Entries)/2]
+	b.ResetTimer()
+	for i := range b.N {
+		fields := entry.Fields()
+		fields.Password = fmt.Sprint("changed ", i)
+		vault.UpdateEntry(entry.ID, fields)
+		if err := store.SaveVault(vault, session); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"vault/internal/crypto"
+)
+
+// Vault file layout (format version 1):
+//
+//	[8-byte magic "VAULTENC"][2-byte version][4-byte header length][header JSON][nonce|ciphertext]
+//
+// The header records everything needed to derive the key and decrypt the
+// payload, and the bytes up to the end of the header are authenticated as
+// GCM additional data so they cannot be altered without detection.
+//
+// Files that do not start with the magic are legacy vaults laid out as
+// [32-byte salt][nonce|ciphertext], encrypted with PBKDF2-SHA256 at
+// crypto.Iterations and AES-256-GCM.
+
+const (
+	FormatVersion       = 1 // Current vault file format version
+	legacyFormatVersion = 0 // Headerless files written before versioning
+
+	maxHeaderLength = 64 * 1024 // Sanity limit for the header JSON
+)
+
+var fileMagic = []byte("VAULTENC")
+
+var (
+	ErrInvalidFormat      = errors.New("invalid vault file format")
+	ErrUnsupportedVersion = errors.New("unsupported vault file version")
+	ErrUnsupportedCipher  = errors.New("unsupported cipher")
+)
+
+// FileHeader describes how the vault payload was encrypted
+type FileHeader struct {
+	KDF    crypto.KDFParams `json:"kdf"`
+	Cipher string           `json:"cipher"`
+	Salt   []byte           `json:"salt"`
+}
+
+// vaultFile is a parsed vault file
+type vaultFile struct {
+	Version int
+	Header  FileHeader
+	AAD     []byte // Authenticated prefix, nil for legacy files
+	Payload []byte // nonce|ciphertext
+
+	Revision [sha256.Size]byte // Hash of the whole file, set when read from disk
+}
+
+// encodeHeader returns the authenticated file prefix for the given header
+func encodeHeader(header FileHeader) ([]byte, error) {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vault header: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(fileMagic)
+	binary.Write(&buf, binary.BigEndian, uint16(FormatVersion))
+	binary.Write(&buf, binary.BigEndian, uint32(len(headerJSON)))
+	buf.Write(headerJSON)
+	return buf.Bytes(), nil
+}
+
+// parseVaultFile splits raw file data into its header and payload
+func parseVaultFile(data []byte) (*vaultFile, error) {
+	if !bytes.HasPrefix(data, fileMagic) {
+		return parseLegacyFile(data)
+	}
+
+	rest := data[len(fileMagic):]
+	if len(rest) < 6 {
+		return nil, ErrInvalidFormat
+	}
+
+	version := int(binary.BigEndian.Uint16(rest[:2]))
+	if version != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	headerLen := int(binary.BigEndian.Uint32(rest[2:6]))
+	rest = rest[6:]
+	if headerLen > maxHeaderLength || headerLen > len(rest) {
+		return nil, ErrInvalidFormat
+	}
+
+	var header FileHeader
+	if err := json.Unmarshal(rest[:headerLen], &header); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %v", ErrInvalidFormat, err)
+	}
+	if header.Cipher != crypto.CipherAES256GCM {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedCipher, header.Cipher)
+	}
+	if len(header.Salt) == 0 {
+		return nil, fmt.Errorf("%w: missing salt", ErrInvalidFormat)
+	}
+
+	prefixLen := len(data) - len(rest) + headerLen
+	return &vaultFile{
+		Version: version,
+		Header:  header,
+		AAD:     data[:prefixLen],
+		Payload: data[prefixLen:],
+	}, nil
+}
+
+// parseLegacyFile handles the original headerless [salt][ciphertext] layout
+func parseLegacyFile(data []byte) (*vaultFile, error) {
+	if len(data) < crypto.SaltLength {
+		return nil, ErrInvalidFormat
+	}
+
+	return &vaultFile{
+		Version: legacyFormatVersion,
+		Header: FileHeader{
+			KDF:    crypto.LegacyKDFParams(),
+			Cipher: crypto.CipherAES256GCM,
+			Salt:   data[:crypto.SaltLength],
+		},
+		Payload: data[crypto.SaltLength:],
+	}, nil
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+
+	"vault/internal/crypto"
+)
+
+func testHeader() FileHeader {
+	return FileHeader{
+		KDF:    crypto.DefaultKDFParams(),
+		Cipher: crypto.CipherAES256GCM,
+		Salt:   bytes.Repeat([]byte{0xa5}, crypto.SaltLength),
+	}
+}
+
+// rawFile lays out a vault file by hand, so headers encodeHeader would
+// never write can be tried
+func rawFile(version uint16, headerLen uint32, header string, payload string) []byte {
+	var buf bytes.Buffer
+	buf.Write(fileMagic)
+	binary.Write(&buf, binary.BigEndian, version)
+	binary.Write(&buf, binary.BigEndian, headerLen)
+	buf.WriteString(header)
+	buf.WriteString(payload)
+	return buf.Bytes()
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	header := testHeader()
+	prefix, err := encodeHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte("nonce and ciphertext")
+
+	file, err := parseVaultFile(append(bytes.Clone(prefix), payload...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != FormatVersion {
+		t.Errorf("version %d, want %d", file.Version, FormatVersion)
+	}
+	if !reflect.DeepEqual(file.Header, header) {
+		t.Errorf("header %+v, want %+v", file.Header, header)
+	}
+	if !bytes.Equal(file.AAD, prefix) {
+		t.Errorf("authenticated prefix %q, want %q", file.AAD, prefix)
+	}
+	if !bytes.Equal(file.Payload, payload) {
+		t.Errorf("payload %q, want %q", file.Payload, payload)
+	}
+}
+
+func TestTruncatedHeader(t *testing.T) {
+	prefix, err := encodeHeader(testHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := range len(prefix) {
+		if _, err := parseVaultFile(prefix[:n]); !errors.Is(err, ErrInvalidFormat) {
+			t.Errorf("first %d of %d bytes: got %v, want ErrInvalidFormat", n, len(prefix), err)
+		}
+	}
+}
+
+func TestGarbageHeader(t *testing.T) {
+	valid := `{"kdf":{"name":"argon2id","memory":65536,"time":3,"parallelism":4},"cipher":"aes-256-gcm","salt":"AAAA"}`
+	withHeader := func(header string) []byte {
+		return rawFile(FormatVersion, uint32(len(header)), header, "payload")
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"future version", rawFile(FormatVersion+1, uint32(len(valid)), valid, "payload"), ErrUnsupportedVersion},
+		{"header past the end", rawFile(FormatVersion, uint32(len(valid))+1, valid, ""), ErrInvalidFormat},
+		{"header over the limit", rawFile(FormatVersion, maxHeaderLength+1, valid, string(make([]byte, maxHeaderLength))), ErrInvalidFormat},
+		{"header length overflowing", rawFile(FormatVersion, 0xffffffff, valid, "payload"), ErrInvalidFormat},
+		{"not JSON", withHeader("not json!"), ErrInvalidFormat},
+		{"JSON of the wrong shape", withHeader("[]"), ErrInvalidFormat},
+		{"empty header", withHeader(""), ErrInvalidFormat},
+		{"unknown cipher", withHeader(`{"cipher":"rot13","salt":"AAAA"}`), ErrUnsupportedCipher},
+		{"missing salt", withHeader(`{"cipher":"aes-256-gcm"}`), ErrInvalidFormat},
+		{"short legacy file", bytes.Repeat([]byte{0x5a}, crypto.SaltLength-1), ErrInvalidFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseVaultFile(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyFile(t *testing.T) {
+	salt := bytes.Repeat([]byte{0x5a}, crypto.SaltLength)
+	file, err := parseVaultFile(append(bytes.Clone(salt), "payload"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.Version != legacyFormatVersion || file.AAD != nil || string(file.Payload) != "payload" {
+		t.Errorf("parsed %+v", file)
+	}
+	if !bytes.Equal(file.Header.Salt, salt) || file.Header.KDF != crypto.LegacyKDFParams() {
+		t.Errorf("header %+v", file.Header)
+	}
+}
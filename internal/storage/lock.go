@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const lockSuffix = ".lock"
+
+var ErrLocked = errors.New("vault is in use by another process")
+
+// LockedError reports which process holds the vault lock
+type LockedError struct {
+	PID int // 0 if the holder could not be determined
+}
+
+func (e *LockedError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("vault is in use by PID %d", e.PID)
+	}
+	return ErrLocked.Error()
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// LockPath returns the path of the lock file guarding the vault
+func (s *Storage) LockPath() string {
+	return s.filePath + lockSuffix
+}
+
+// Lock takes the exclusive advisory lock on the vault and holds it until
+// Unlock, so a command owns the vault for its whole read-modify-write
+// cycle. It fails with a *LockedError if another process holds it.
+func (s *Storage) Lock() error {
+	if s.lock != nil {
+		return nil
+	}
+
+	if err := s.EnsureVaultDir(); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.LockPath(), os.O_RDWR|os.O_CREATE, VaultPermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		pid := readLockPID(file)
+		file.Close()
+		if errors.Is(err, errWouldBlock) {
+			return &LockedError{PID: pid}
+		}
+		return fmt.Errorf("failed to lock vault: %w", err)
+	}
+
+	// Record our PID so a second opener can say who holds the vault
+	file.Truncate(0)
+	file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	file.Sync()
+
+	s.lock = file
+	return nil
+}
+
+// Unlock releases the vault lock if held
+func (s *Storage) Unlock() error {
+	if s.lock == nil {
+		return nil
+	}
+
+	file := s.lock
+	s.lock = nil
+	file.Truncate(0)
+	unlockFile(file)
+	return file.Close()
+}
+
+// withLock runs fn while holding the vault lock, taking it just for the
+// call if this storage does not already hold it
+func (s *Storage) withLock(fn func() error) error {
+	if s.lock != nil {
+		return fn()
+	}
+
+	if err := s.Lock(); err != nil {
+		return err
+	}
+	defer s.Unlock()
+	return fn()
+}
+
+// readLockPID reads the holder's PID from the lock file, or 0 if unknown
+func readLockPID(file *os.File) int {
+	buf := make([]byte, 32)
+	n, _ := file.ReadAt(buf, 0)
+	pid, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"vault/internal/crypto"
 	"vault/internal/models"
@@ -13,11 +17,43 @@ import (
 const (
 	DefaultVaultFile = "vault.enc"
 	VaultPermissions = 0600 // Owner read/write only
+
+	DefaultTrashRetention = 30 * 24 * time.Hour // Deleted entries are purged after this
+)
+
+var (
+	ErrVaultNotFound = errors.New("vault file does not exist")
+	ErrWrongPassword = errors.New("invalid master password or corrupted vault")
+	ErrCorrupt       = errors.New("corrupted vault data")
+	ErrVerification  = errors.New("written vault failed verification")
+	ErrExternalRekey = errors.New("vault was re-encrypted by another process; unlock again to continue")
 )
 
+// ConflictError is returned by SaveVault when the vault had been changed by
+// another process and some entries were edited on both sides. The save has
+// still happened, with each conflict resolved as described in Conflicts.
+type ConflictError struct {
+	Conflicts []models.Conflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("vault was changed elsewhere; %d conflicting entries merged", len(e.Conflicts))
+}
+
 // Storage handles encrypted vault persistence
 type Storage struct {
 	filePath string
+	kdf      crypto.KDFParams // KDF used when creating a new vault
+	backups  BackupPolicy
+	history  models.HistoryPolicy // Applied to every vault this storage returns
+	trash    time.Duration        // How long deleted entries stay in the trash
+	lock     *os.File // Held vault lock, nil if not locked
+
+	// What this storage last read or wrote, to detect changes made by others
+	revision    [sha256.Size]byte
+	base        []models.PasswordEntry
+	baseFolders []models.Folder
+	baseTrash   []models.TrashedEntry
 }
 
 // NewStorage creates a new storage instance
@@ -38,7 +74,50 @@ func NewStorage(filePath string) *Storage {
 			filePath = filepath.Join(homeDir, filePath)
 		}
 	}
-	return &Storage{filePath: filePath}
+	return &Storage{
+		filePath: filePath,
+		kdf:      crypto.DefaultKDFParams(),
+		backups:  DefaultBackupPolicy(),
+		history:  models.DefaultHistoryPolicy(),
+		trash:    DefaultTrashRetention,
+	}
+}
+
+// SetTrashRetention sets how long deleted entries stay in the trash before
+// a save purges them; 0 keeps them until the trash is emptied
+func (s *Storage) SetTrashRetention(retention time.Duration) {
+	s.trash = retention
+}
+
+// TrashRetention returns how long deleted entries stay in the trash
+func (s *Storage) TrashRetention() time.Duration {
+	return s.trash
+}
+
+// SetHistoryPolicy sets how many previous values the entries of vaults
+// loaded or created by this storage keep
+func (s *Storage) SetHistoryPolicy(policy models.HistoryPolicy) {
+	s.history = policy
+}
+
+// SetKDFParams sets the key derivation parameters used for vaults created by
+// this storage. Existing vaults keep the parameters recorded in their header.
+func (s *Storage) SetKDFParams(params crypto.KDFParams) {
+	s.kdf = params
+}
+
+// ReadHeader reads the vault file header without decrypting the payload
+func (s *Storage) ReadHeader() (int, FileHeader, error) {
+	fileData, err := os.ReadFile(s.filePath)
+	if err != nil {
+		return 0, FileHeader{}, fmt.Errorf("failed to read vault file: %w", err)
+	}
+
+	file, err := parseVaultFile(fileData)
+	if err != nil {
+		return 0, FileHeader{}, err
+	}
+	return file.Version, file.Header, nil
 }
 
 // EnsureVaultDir creates the vault directory if it doesn't exist
@@ -56,50 +135,214 @@ func (s *Storage) VaultExists() bool {
 	return !os.IsNotExist(err)
 }
 
-// SaveVault encrypts and saves the vault to disk
-func (s *Storage) SaveVault(vault *models.Vault, masterPassword string) error {
+// SaveVault encrypts the vault with the session key and saves it to disk,
+// holding the vault lock for the write. A *ConflictError or *SyncError
+// still means the vault was saved.
+func (s *Storage) SaveVault(vault *models.Vault, session *crypto.Session) error {
+	return s.withLock(func() error {
+		return s.saveVault(vault, session)
+	})
+}
+
+func (s *Storage) saveVault(vault *models.Vault, session *crypto.Session) error {
+	key, err := session.Key()
+	if err != nil {
+		return err
+	}
+
 	// Ensure vault directory exists
 	if err := s.EnsureVaultDir(); err != nil {
 		return err
 	}
 
+	// The salt travels with the key it produced
+	vault.Salt = session.Salt()
+
+	// Build the self-describing header, authenticated alongside the payload
+	header, err := encodeHeader(FileHeader{
+		KDF:    session.KDFParams(),
+		Cipher: crypto.CipherAES256GCM,
+		Salt:   session.Salt(),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Merge in changes written by someone else since we loaded
+	conflicts, err := s.mergeExternalChanges(vault, session)
+	if err != nil {
+		return err
+	}
+
+	// Deleted entries past their time go for good
+	vault.PurgeTrash(s.trash, time.Now())
+
 	// Marshal vault to JSON
 	jsonData, err := json.Marshal(vault)
 	if err != nil {
 		return fmt.Errorf("failed to marshal vault: %w", err)
 	}
-
-	// Derive encryption key from master password
-	key := crypto.DeriveKey(masterPassword, vault.Salt)
-	defer crypto.SecureWipe(key)
+	defer crypto.SecureWipe(jsonData)
 
 	// Encrypt the vault data
-	encryptedData, err := crypto.Encrypt(jsonData, key)
+	encryptedData, err := crypto.EncryptWithAAD(jsonData, key, header)
 	if err != nil {
 		return fmt.Errorf("failed to encrypt vault: %w", err)
 	}
 
-	// Create the file format: [32-byte salt][encrypted data]
-	fileData := make([]byte, len(vault.Salt)+len(encryptedData))
-	copy(fileData[:len(vault.Salt)], vault.Salt)
-	copy(fileData[len(vault.Salt):], encryptedData)
+	// Create the file format: [header][encrypted data]
+	fileData := append(header, encryptedData...)
 
-	// Write data to file with secure permissions
-	if err := os.WriteFile(s.filePath, fileData, VaultPermissions); err != nil {
+	// Re-decrypt what actually reached the disk before it replaces the vault
+	verify := func(tmpPath string) error {
+		return verifyVaultFile(tmpPath, key, jsonData)
+	}
+
+	// Keep the generation being replaced
+	if err := s.backupCurrent(); err != nil {
+		return fmt.Errorf("failed to back up vault: %w", err)
+	}
+
+	// Replace the vault file in one step so a failed write leaves the old one
+	err = writeFileAtomic(s.filePath, fileData, VaultPermissions, verify)
+	var syncErr *SyncError
+	if err != nil && !errors.As(err, &syncErr) {
 		return fmt.Errorf("failed to write vault file: %w", err)
 	}
 
-	// Clear sensitive data from memory
-	crypto.SecureWipe(jsonData)
+	s.track(fileData, vault)
 
-	return nil
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return err // Nil, or a *SyncError for a vault that was still written
 }
 
-// LoadVault loads and decrypts the vault from disk
-func (s *Storage) LoadVault(masterPassword string) (*models.Vault, error) {
+// mergeExternalChanges checks whether the vault file changed on disk since
+// this storage last read or wrote it and, if so, merges those changes into
+// vault entry by entry. Conflicting edits are resolved provisionally and
+// returned for the caller to review.
+func (s *Storage) mergeExternalChanges(vault *models.Vault, session *crypto.Session) ([]models.Conflict, error) {
+	var zero [sha256.Size]byte
+	if s.revision == zero || !s.VaultExists() {
+		return nil, nil
+	}
+
+	file, err := s.readVaultFile()
+	if err != nil {
+		return nil, err
+	}
+	if file.Revision == s.revision {
+		return nil, nil
+	}
+
+	if !bytes.Equal(file.Header.Salt, session.Salt()) {
+		return nil, ErrExternalRekey
+	}
+
+	theirs, err := decryptVaultFile(file, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read externally modified vault: %w", err)
+	}
+
+	merged, conflicts := models.MergeEntries(s.base, vault.Entries, theirs.Entries)
+	for i, conflict := range conflicts {
+		// Keep when the entry was deleted, should the deletion be chosen
+		deleter := vault
+		if conflict.Remote == nil {
+			deleter = theirs
+		}
+		if conflict.Local == nil || conflict.Remote == nil {
+			if trashed, ok := deleter.GetTrashed(conflict.ID); ok {
+				conflicts[i].DeletedAt = trashed.DeletedAt
+			}
+		}
+	}
+	vault.SetEntries(merged)
+	vault.Folders = models.MergeFolders(s.baseFolders, vault.Folders, theirs.Folders)
+	vault.RepairFolders()
+	vault.Trash = models.MergeTrash(s.baseTrash, vault.Trash, theirs.Trash, vault.Entries)
+	return conflicts, nil
+}
+
+// Forget drops the entries this storage remembers from its last read or
+// write, for when the vault is locked
+func (s *Storage) Forget() {
+	s.revision = [sha256.Size]byte{}
+	s.base = nil
+	s.baseFolders = nil
+	s.baseTrash = nil
+}
+
+// track records the file contents as the revision this storage last saw
+func (s *Storage) track(fileData []byte, vault *models.Vault) {
+	s.revision = sha256.Sum256(fileData)
+	s.remember(vault)
+}
+
+// remember keeps a copy of what the vault held when last read or written,
+// the base of the next merge
+func (s *Storage) remember(vault *models.Vault) {
+	s.base = append([]models.PasswordEntry(nil), vault.Entries...)
+	s.baseFolders = append([]models.Folder(nil), vault.Folders...)
+	s.baseTrash = append([]models.TrashedEntry(nil), vault.Trash...)
+}
+
+// LoadVault unlocks the vault with the master password, returning it along
+// with a session holding the derived key for subsequent saves
+func (s *Storage) LoadVault(masterPassword string) (*models.Vault, *crypto.Session, error) {
+	file, err := s.readVaultFile()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Derive the key once; only the session keeps it from here on
+	session, err := crypto.NewSession(masterPassword, file.Header.Salt, file.Header.KDF)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	vault, err := decryptVaultFile(file, session)
+	if err != nil {
+		session.Wipe()
+		return nil, nil, err
+	}
+
+	s.revision = file.Revision
+	s.remember(vault)
+	vault.SetHistoryPolicy(s.history)
+
+	return vault, session, nil
+}
+
+// Reload reads the vault again with an existing session, picking up changes
+// written by other processes without re-deriving the key
+func (s *Storage) Reload(session *crypto.Session) (*models.Vault, error) {
+	file, err := s.readVaultFile()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(file.Header.Salt, session.Salt()) {
+		return nil, ErrExternalRekey
+	}
+
+	vault, err := decryptVaultFile(file, session)
+	if err != nil {
+		return nil, err
+	}
+
+	s.revision = file.Revision
+	s.remember(vault)
+	vault.SetHistoryPolicy(s.history)
+
+	return vault, nil
+}
+
+// readVaultFile reads and parses the vault file header
+func (s *Storage) readVaultFile() (*vaultFile, error) {
 	// Check if vault file exists
 	if !s.VaultExists() {
-		return nil, fmt.Errorf("vault file does not exist: %s", s.filePath)
+		return nil, fmt.Errorf("%w: %s", ErrVaultNotFound, s.filePath)
 	}
 
 	// Read file data
@@ -108,56 +351,128 @@ func (s *Storage) LoadVault(masterPassword string) (*models.Vault, error) {
 		return nil, fmt.Errorf("failed to read vault file: %w", err)
 	}
 
-	// File format: [32-byte salt][encrypted data]
-	if len(fileData) < crypto.SaltLength {
-		return nil, fmt.Errorf("invalid vault file format")
+	// Dispatch on the file header; headerless files are legacy vaults
+	file, err := parseVaultFile(fileData)
+	if err != nil {
+		return nil, err
 	}
+	file.Revision = sha256.Sum256(fileData)
+	return file, nil
+}
 
-	// Extract salt and encrypted data
-	salt := fileData[:crypto.SaltLength]
-	encryptedData := fileData[crypto.SaltLength:]
-
-	// Derive encryption key from master password and salt
-	key := crypto.DeriveKey(masterPassword, salt)
-	defer crypto.SecureWipe(key)
+// decryptVaultFile decrypts and parses a vault file with the session key
+func decryptVaultFile(file *vaultFile, session *crypto.Session) (*models.Vault, error) {
+	key, err := session.Key()
+	if err != nil {
+		return nil, err
+	}
 
 	// Decrypt the vault data
-	jsonData, err := crypto.Decrypt(encryptedData, key)
+	jsonData, err := crypto.DecryptWithAAD(file.Payload, key, file.AAD)
 	if err != nil {
-		return nil, fmt.Errorf("invalid master password or corrupted vault")
+		return nil, ErrWrongPassword
 	}
+	defer crypto.SecureWipe(jsonData)
 
 	// Parse the decrypted JSON
 	var vault models.Vault
 	if err := json.Unmarshal(jsonData, &vault); err != nil {
-		crypto.SecureWipe(jsonData)
-		return nil, fmt.Errorf("corrupted vault data")
+		return nil, ErrCorrupt
 	}
 
-	// Clear sensitive data from memory
-	crypto.SecureWipe(jsonData)
+	// The header salt is authoritative
+	vault.Salt = append([]byte(nil), file.Header.Salt...)
 
 	return &vault, nil
 }
 
+// verifyVaultFile checks that the file at path decrypts to the expected plaintext
+func verifyVaultFile(path string, key []byte, expected []byte) error {
+	written, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	file, err := parseVaultFile(written)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	plaintext, err := crypto.DecryptWithAAD(file.Payload, key, file.AAD)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+	defer crypto.SecureWipe(plaintext)
+
+	if !bytes.Equal(plaintext, expected) {
+		return fmt.Errorf("%w: contents differ", ErrVerification)
+	}
+	return nil
+}
 
 // CreateNewVault creates a new encrypted vault with a random salt
-func (s *Storage) CreateNewVault(masterPassword string) (*models.Vault, error) {
+func (s *Storage) CreateNewVault(masterPassword string) (*models.Vault, *crypto.Session, error) {
 	// Generate a random salt
 	salt, err := crypto.GenerateSalt()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate salt: %w", err)
+		return nil, nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	session, err := crypto.NewSession(masterPassword, salt, s.kdf)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key: %w", err)
 	}
 
 	// Create new vault with the salt
 	vault := models.NewVault(salt)
+	vault.SetHistoryPolicy(s.history)
 
 	// Save the empty vault
-	if err := s.SaveVault(vault, masterPassword); err != nil {
-		return nil, fmt.Errorf("failed to save new vault: %w", err)
+	var syncErr *SyncError
+	if err := s.SaveVault(vault, session); err != nil && !errors.As(err, &syncErr) {
+		session.Wipe()
+		return nil, nil, fmt.Errorf("failed to save new vault: %w", err)
 	}
 
-	return vault, nil
+	return vault, session, nil
+}
+
+// ChangeMasterPassword verifies oldPassword against the vault on disk, then
+// re-encrypts it under newPassword with a fresh salt and the given KDF
+// parameters. The returned vault and session reflect what was written.
+func (s *Storage) ChangeMasterPassword(oldPassword, newPassword string, params crypto.KDFParams) (vault *models.Vault, session *crypto.Session, err error) {
+	err = s.withLock(func() error {
+		vault, session, err = s.changeMasterPassword(oldPassword, newPassword, params)
+		return err
+	})
+	return vault, session, err
+}
+
+func (s *Storage) changeMasterPassword(oldPassword, newPassword string, params crypto.KDFParams) (*models.Vault, *crypto.Session, error) {
+	vault, oldSession, err := s.LoadVault(oldPassword)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldSession.Wipe()
+
+	salt, err := crypto.GenerateSalt()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := crypto.NewSession(newPassword, salt, params)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	// Once written the vault is under the new password, synced or not
+	var syncErr *SyncError
+	if err := s.saveVault(vault, session); err != nil && !errors.As(err, &syncErr) {
+		session.Wipe()
+		return nil, nil, fmt.Errorf("failed to re-encrypt vault: %w", err)
+	}
+
+	return vault, session, nil
 }
 
 // GetVaultPath returns the path to the vault file
@@ -170,7 +485,6 @@ func (s *Storage) DeleteVault() error {
 	if !s.VaultExists() {
 		return nil // Already deleted
 	}
-	
 	if err := os.Remove(s.filePath); err != nil {
 		return fmt.Errorf("failed to delete vault file: %w", err)
 	}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"vault/internal/crypto"
+	"vault/internal/models"
+)
+
+const testPassword = "correct horse battery staple"
+
+// newTestStorage returns a storage for a vault in a temporary directory,
+// with key derivation cheap enough for tests
+func newTestStorage(t *testing.T) *Storage {
+	t.Helper()
+	s := NewStorage(filepath.Join(t.TempDir(), "vault.enc"))
+	s.SetKDFParams(crypto.KDFParams{Name: crypto.KDFArgon2id, Memory: 8, Time: 1, Parallelism: 1})
+	return s
+}
+
+func TestConflictWithDeletionKeepsTrashTime(t *testing.T) {
+	ours := newTestStorage(t)
+	vault, session, err := ours.CreateNewVault(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := models.NewPasswordEntry(models.EntryFields{Title: "github", Password: "p"})
+	vault.AddEntry(entry)
+	if err := ours.SaveVault(vault, session); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process deletes the entry while we edit it
+	other := NewStorage(ours.GetVaultPath())
+	theirVault, theirSession, err := other.LoadVault(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirVault.DeleteEntry(entry.ID)
+	if err := other.SaveVault(theirVault, theirSession); err != nil {
+		t.Fatal(err)
+	}
+	deleted, _ := theirVault.GetTrashed(entry.ID)
+
+	fields := entry.Fields()
+	fields.Title = "github edited"
+	vault.UpdateEntry(entry.ID, fields)
+
+	var conflictErr *ConflictError
+	if err := ours.SaveVault(vault, session); !errors.As(err, &conflictErr) || len(conflictErr.Conflicts) != 1 {
+		t.Fatalf("got %v, want one conflict", err)
+	}
+	conflict := conflictErr.Conflicts[0]
+	if conflict.Remote != nil || !conflict.DeletedAt.Equal(deleted.DeletedAt) {
+		t.Fatalf("conflict %+v, want a remote deletion at %s", conflict, deleted.DeletedAt)
+	}
+
+	// Siding with the deletion puts the entry back in the trash
+	vault.ResolveConflict(conflict, true)
+	if err := ours.SaveVault(vault, session); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, reloadedSession, err := NewStorage(ours.GetVaultPath()).LoadVault(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloadedSession.Wipe()
+	trashed, ok := reloaded.GetTrashed(entry.ID)
+	if !ok || !trashed.DeletedAt.Equal(deleted.DeletedAt) || trashed.Title != "github edited" {
+		t.Errorf("trash %+v, want the edited entry deleted at %s", reloaded.Trash, deleted.DeletedAt)
+	}
+	if _, ok := reloaded.GetEntry(entry.ID); ok {
+		t.Error("entry still in the vault")
+	}
+}
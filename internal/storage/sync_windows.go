@@ -0,0 +1,9 @@
+//go:build windows
+
+package storage
+
+// syncDir is a no-op on Windows, where directories cannot be opened and
+// synced like regular files
+func syncDir(dir string) error {
+	return nil
+}
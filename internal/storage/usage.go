@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"vault/internal/crypto"
+	"vault/internal/models"
+)
+
+// When entries were last used is kept in a file of its own beside the
+// vault, encrypted with the same key. Using an entry then neither rewrites
+// the vault nor pushes a backup out, and works while another process holds
+// the vault lock; two processes recording at once may lose one use.
+const (
+	usageSuffix = ".usage"
+	maxUsage    = 1000 // Entries remembered; the least recently used go first
+)
+
+// usageAAD binds the usage file's ciphertext to its purpose, so it cannot
+// pass for a vault
+var usageAAD = []byte("vault usage")
+
+// UsagePath returns the path of the file recording when entries were used
+func (s *Storage) UsagePath() string {
+	return s.filePath + usageSuffix
+}
+
+// LoadUsage reads when each entry was last used. A missing file, or one
+// the session cannot open because the master password changed since, is
+// read as empty: usage only ranks search results.
+func (s *Storage) LoadUsage(session *crypto.Session) models.Usage {
+	usage := make(models.Usage)
+	data, err := os.ReadFile(s.UsagePath())
+	if err != nil {
+		return usage
+	}
+	key, err := session.Key()
+	if err != nil {
+		return usage
+	}
+	plaintext, err := crypto.DecryptWithAAD(data, key, usageAAD)
+	if err != nil {
+		return usage
+	}
+	json.Unmarshal(plaintext, &usage)
+	return usage
+}
+
+// RecordUse notes that an entry was used at t, returning the usage as now
+// recorded
+func (s *Storage) RecordUse(session *crypto.Session, id string, t time.Time) (models.Usage, error) {
+	usage := s.LoadUsage(session).Used(id, t)
+	if len(usage) > maxUsage {
+		ids := make([]string, 0, len(usage))
+		for id := range usage {
+			ids = append(ids, id)
+		}
+		slices.SortFunc(ids, func(a, b string) int {
+			return usage[b].Compare(usage[a])
+		})
+		for _, id := range ids[maxUsage:] {
+			delete(usage, id)
+		}
+	}
+
+	key, err := session.Key()
+	if err != nil {
+		return usage, err
+	}
+	plaintext, err := json.Marshal(usage)
+	if err != nil {
+		return usage, err
+	}
+	data, err := crypto.EncryptWithAAD(plaintext, key, usageAAD)
+	if err != nil {
+		return usage, err
+	}
+	if err := s.EnsureVaultDir(); err != nil {
+		return usage, err
+	}
+	var syncErr *SyncError
+	if err := writeFileAtomic(s.UsagePath(), data, VaultPermissions, nil); err != nil && !errors.As(err, &syncErr) {
+		return usage, fmt.Errorf("failed to record use: %w", err)
+	}
+	return usage, nil
+}
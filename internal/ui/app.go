@@ -1,13 +1,18 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
-	"os/exec"
-	"runtime"
+	"math"
+	"os"
+	"time"
 
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/clipboard"
+	"vault/internal/config"
+	"vault/internal/crypto"
 	"vault/internal/models"
+	"vault/internal/otp"
 	"vault/internal/storage"
 )
 
@@ -20,35 +25,85 @@ const (
 	StateDetail
 	StateForm
 	StateConfirmDelete
+	StateChangePassword
+	StateBackups
+	StateConflicts
+	StateTrash
 )
 
 // AppModel is the main application model
 type AppModel struct {
 	state         AppState
 	storage       *storage.Storage
+	config        *config.Config
 	vault         *models.Vault
-	masterPassword string
+	session       *crypto.Session // Derived key; the master password is not kept
+	usage         models.Usage    // When entries were last used, for ranking searches
 	
 	// Screen models
 	loginModel    LoginModel
 	listModel     ListModel
 	detailModel   DetailModel
 	formModel     FormModel
+	changePasswordModel ChangePasswordModel
+	backupsModel  BackupsModel
+	conflictsModel ConflictsModel
+	trashModel    TrashModel
 	
 	// Temporary state
 	pendingDeleteID string
+	pendingFolderID string    // Folder awaiting delete confirmation, if any
+	undo            undoStack // Changes made since unlocking, for u and ctrl+r
+
+	// Inactivity lock
+	idleLock     time.Duration // 0 never locks on idle
+	lastActivity time.Time
+	unlockGen    int // Counts unlocks so checks scheduled for an earlier session are ignored
+
+	// Clipboard clearing
+	clipboardClear       time.Duration // 0 leaves copied secrets in place
+	clipboardAt          time.Time     // When the last copied secret is cleared
+	clipboardFingerprint string
+	clipboardHelper      *os.Process // Detached process that clears it, nil if it could not start
+	clipboardGen         int         // Counts copies so ticks for an earlier one are ignored
+}
+
+// idleCheckMsg asks the app to check for inactivity in the session it was
+// scheduled for
+type idleCheckMsg struct {
+	gen int
+}
+
+// clipboardTickMsg updates the countdown for the copy it was scheduled for
+type clipboardTickMsg struct {
+	gen int
 }
 
 // NewAppModel creates a new application model
 func NewAppModel(vaultPath string) AppModel {
 	storage := storage.NewStorage(vaultPath)
 	isNewVault := !storage.VaultExists()
+	loginModel := NewLoginModel(isNewVault)
+
+	// New vaults use the configured key derivation parameters
+	cfg, err := config.Load(config.Path(storage.GetVaultPath()))
+	if err != nil {
+		loginModel = loginModel.SetError(err.Error())
+		cfg = &config.Config{}
+	}
+	storage.SetKDFParams(cfg.KDFParams())
+	storage.SetBackupPolicy(cfg.BackupPolicy())
+	storage.SetHistoryPolicy(cfg.HistoryPolicy())
+	storage.SetTrashRetention(cfg.TrashRetention())
 	
 	return AppModel{
 		state:       StateLogin,
 		storage:     storage,
-		loginModel:  NewLoginModel(isNewVault),
+		config:      cfg,
+		loginModel:  loginModel,
 		listModel:   NewListModel([]models.PasswordEntry{}),
+		idleLock:    cfg.IdleLock(),
+		clipboardClear: cfg.ClipboardClear(),
 	}
 }
 
@@ -62,6 +117,13 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+		m.lastActivity = time.Now()
+
+	case idleCheckMsg:
+		return m.handleIdleCheck(msg)
+
+	case clipboardTickMsg:
+		return m.handleClipboardTick(msg)
 	}
 
 	switch m.state {
@@ -75,6 +137,14 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.handleFormState(msg)
 	case StateConfirmDelete:
 		return m.handleConfirmDeleteState(msg)
+	case StateChangePassword:
+		return m.handleChangePasswordState(msg)
+	case StateBackups:
+		return m.handleBackupsState(msg)
+	case StateTrash:
+		return m.handleTrashState(msg)
+	case StateConflicts:
+		return m.handleConflictsState(msg)
 	}
 
 	return m, nil
@@ -86,33 +156,88 @@ func (m AppModel) handleLoginState(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.loginModel = model.(LoginModel)
 
 	if result, ok := msg.(LoginResult); ok && result.Success {
-		m.masterPassword = result.Password
-		
 		if result.IsNewVault {
-			vault, err := m.storage.CreateNewVault(m.masterPassword)
+			vault, session, err := m.storage.CreateNewVault(result.Password)
 			if err != nil {
 				m.loginModel = m.loginModel.SetError("Failed to create vault: " + err.Error())
 				return m, nil
 			}
 			m.vault = vault
+			m.session = session
 			m.listModel = m.listModel.SetStatus("vault created")
 		} else {
-			vault, err := m.storage.LoadVault(m.masterPassword)
+			vault, session, err := m.storage.LoadVault(result.Password)
 			if err != nil {
 				m.loginModel = m.loginModel.SetError("Failed to unlock vault: " + err.Error())
 				return m, nil
 			}
 			m.vault = vault
+			m.session = session
 			m.listModel = m.listModel.SetStatus(fmt.Sprintf("%d entries loaded", len(vault.Entries)))
 		}
 
-		m.listModel = m.listModel.UpdateEntries(m.vault.Entries)
+		m.usage = m.storage.LoadUsage(m.session)
+		m.listModel = m.listModel.SetUsage(m.usage).UpdateVault(m.vault)
 		m.state = StateList
+
+		m.unlockGen++
+		m.lastActivity = time.Now()
+		return m, tea.Batch(cmd, m.scheduleIdleCheck(m.idleLock))
 	}
 
 	return m, cmd
 }
 
+// scheduleIdleCheck checks for inactivity after the given delay
+func (m AppModel) scheduleIdleCheck(after time.Duration) tea.Cmd {
+	if m.idleLock <= 0 {
+		return nil
+	}
+	gen := m.unlockGen
+	return tea.Tick(after, func(time.Time) tea.Msg {
+		return idleCheckMsg{gen: gen}
+	})
+}
+
+// handleIdleCheck locks the vault once no key has been pressed for the idle
+// lock period, and otherwise checks again when it would next expire
+func (m AppModel) handleIdleCheck(msg idleCheckMsg) (tea.Model, tea.Cmd) {
+	if msg.gen != m.unlockGen || m.session == nil {
+		return m, nil
+	}
+
+	idle := time.Since(m.lastActivity)
+	if idle >= m.idleLock {
+		return m.lock(fmt.Sprintf("locked after %d minutes of inactivity", int(m.idleLock/time.Minute)))
+	}
+	return m, m.scheduleIdleCheck(m.idleLock - idle)
+}
+
+// lock wipes the key and decrypted entries from memory and returns to the
+// login screen with the given message
+func (m AppModel) lock(message string) (tea.Model, tea.Cmd) {
+	m.session.Wipe()
+	m.session = nil
+	m.vault = nil
+	m.usage = nil
+	m.unlockGen++
+
+	m.listModel = m.listModel.SetUsage(nil).UpdateVault(nil).ClearStatus()
+	m.detailModel = DetailModel{}
+	m.formModel = FormModel{}
+	m.changePasswordModel = ChangePasswordModel{}
+	m.conflictsModel = ConflictsModel{}
+	m.pendingDeleteID = ""
+	m.pendingFolderID = ""
+	m.undo = undoStack{}
+
+	m.storage.Forget()
+
+	m.loginModel = NewLoginModel(false).SetMessage(message)
+	m.state = StateLogin
+	return m, m.loginModel.Init()
+}
+
 func (m AppModel) handleListState(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	model, cmd := m.listModel.Update(msg)
@@ -128,13 +253,14 @@ func (m AppModel) handleListState(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case ListActionAdd:
-			m.formModel = NewFormModel(false, nil)
+			m.formModel = NewFormModel(false, nil).SetTags(m.vault.Tags()).
+				SetFolder(result.FolderID, m.vault.FolderPath(result.FolderID))
 			m.state = StateForm
 			return m, m.formModel.Init()
 
 		case ListActionEdit:
 			if result.Entry != nil {
-				m.formModel = NewFormModel(true, result.Entry)
+				m.formModel = NewFormModel(true, result.Entry).SetTags(m.vault.Tags())
 				m.state = StateForm
 				return m, m.formModel.Init()
 			}
@@ -147,12 +273,92 @@ func (m AppModel) handleListState(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case ListActionCopy:
 			if result.Entry != nil {
-				if err := m.copyToClipboard(result.Entry.Password); err != nil {
-					m.listModel = m.listModel.SetStatus("failed to copy password")
+				var copyCmd tea.Cmd
+				m, copyCmd = m.recordUse(result.EntryID).copySecret(result.Entry.Password, "password")
+				return m, tea.Batch(cmd, copyCmd)
+			}
+
+		case ListActionCopyOTP:
+			if result.Entry != nil {
+				var copyCmd tea.Cmd
+				m, copyCmd = m.recordUse(result.EntryID).copyOTP(result.Entry)
+				return m, tea.Batch(cmd, copyCmd)
+			}
+
+		case ListActionChangePassword:
+			m.changePasswordModel = NewChangePasswordModel()
+			m.state = StateChangePassword
+			return m, m.changePasswordModel.Init()
+
+		case ListActionBackups:
+			backups, err := m.storage.ListBackups()
+			if err != nil {
+				m.listModel = m.listModel.SetStatus("failed to list backups")
+				return m, cmd
+			}
+			m.backupsModel = NewBackupsModel(backups)
+			m.state = StateBackups
+			return m, m.backupsModel.Init()
+
+		case ListActionTrash:
+			m.trashModel = NewTrashModel(m.vault, m.storage.TrashRetention())
+			m.state = StateTrash
+			return m, m.trashModel.Init()
+
+		case ListActionLock:
+			return m.lock("vault locked")
+
+		case ListActionMove:
+			before := m.vault.Snapshot()
+			entry, _ := m.vault.GetEntry(result.EntryID)
+			if err := m.vault.MoveEntry(result.EntryID, result.FolderID); err != nil {
+				m.listModel = m.listModel.SetStatus("failed to move entry: " + err.Error())
+				return m, cmd
+			}
+			destination := m.vault.FolderPath(result.FolderID)
+			if destination == "" {
+				destination = "top level"
+			}
+			m = m.commit("move of "+entry.Title, before, "moved to "+destination)
+
+		case ListActionNewFolder:
+			before := m.vault.Snapshot()
+			if _, err := m.vault.CreateFolder(result.FolderID, result.Name); err != nil {
+				m.listModel = m.listModel.SetStatus("failed to create folder: " + err.Error())
+				return m, cmd
+			}
+			m = m.commit("new folder "+result.Name, before, "folder created")
+
+		case ListActionRenameFolder:
+			before := m.vault.Snapshot()
+			if err := m.vault.RenameFolder(result.FolderID, result.Name); err != nil {
+				m.listModel = m.listModel.SetStatus("failed to rename folder: " + err.Error())
+				return m, cmd
+			}
+			m = m.commit("rename of folder "+result.Name, before, "folder renamed")
+
+		case ListActionFavorite:
+			if result.Entry != nil {
+				before := m.vault.Snapshot()
+				fields := result.Entry.Fields()
+				fields.Favorite = !fields.Favorite
+				m.vault.UpdateEntry(result.EntryID, fields)
+				if fields.Favorite {
+					m = m.commit("marking "+result.Entry.Title+" as a favourite", before, result.Entry.Title+" marked as a favourite")
 				} else {
-					m.listModel = m.listModel.SetStatus("password copied")
+					m = m.commit("unmarking "+result.Entry.Title+" as a favourite", before, result.Entry.Title+" is no longer a favourite")
 				}
 			}
+
+		case ListActionUndo:
+			return m.undoChange()
+
+		case ListActionRedo:
+			return m.redoChange()
+
+		case ListActionDeleteFolder:
+			m.pendingFolderID = result.FolderID
+			m.state = StateConfirmDelete
 		}
 	}
 
@@ -169,19 +375,32 @@ func (m AppModel) handleDetailState(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "back":
 			m.state = StateList
 
+		case "lock":
+			return m.lock("vault locked")
+
 		case "copy":
+			m.state = StateList
 			if result.Entry != nil {
-				if err := m.copyToClipboard(result.Entry.Password); err != nil {
-					m.listModel = m.listModel.SetStatus("failed to copy password")
-				} else {
-					m.listModel = m.listModel.SetStatus("password copied")
+				secret, what := result.Entry.Password, "password"
+				if result.Field != "" {
+					secret, what = result.Value, result.Field
 				}
+				var copyCmd tea.Cmd
+				m, copyCmd = m.recordUse(result.Entry.ID).copySecret(secret, what)
+				return m, tea.Batch(cmd, copyCmd)
 			}
+
+		case "otp":
 			m.state = StateList
+			if result.Entry != nil {
+				var copyCmd tea.Cmd
+				m, copyCmd = m.recordUse(result.Entry.ID).copyOTP(result.Entry)
+				return m, tea.Batch(cmd, copyCmd)
+			}
 
 		case "edit":
 			if result.Entry != nil {
-				m.formModel = NewFormModel(true, result.Entry)
+				m.formModel = NewFormModel(true, result.Entry).SetTags(m.vault.Tags())
 				m.state = StateForm
 				return m, m.formModel.Init()
 			}
@@ -191,6 +410,19 @@ func (m AppModel) handleDetailState(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.pendingDeleteID = result.EntryID
 				m.state = StateConfirmDelete
 			}
+
+		case "restore":
+			if result.Entry != nil {
+				item := result.Entry.History[result.History]
+				fields := result.Entry.Fields()
+				before := m.vault.Snapshot()
+				if err := fields.Restore(item); err != nil || !m.vault.UpdateEntry(result.EntryID, fields) {
+					m.listModel = m.listModel.SetStatus("failed to restore " + result.Entry.HistoryLabel(item))
+					m.state = StateList
+					return m, cmd
+				}
+				m = m.commit("restore of "+result.Entry.Title+"'s "+result.Entry.HistoryLabel(item), before, result.Entry.HistoryLabel(item)+" restored")
+			}
 		}
 	}
 
@@ -206,25 +438,20 @@ func (m AppModel) handleFormState(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if result.Cancelled {
 			m.state = StateList
 		} else {
+			before := m.vault.Snapshot()
 			if result.IsEdit {
-				success := m.vault.UpdateEntry(result.EntryID, result.Title, result.Username, result.Password, result.URL, result.Notes)
-				if success {
-					m.listModel = m.listModel.SetStatus("password updated")
-				} else {
+				success := m.vault.UpdateEntry(result.EntryID, result.Fields)
+				if !success {
 					m.listModel = m.listModel.SetStatus("failed to update password")
+					m.state = StateList
+					return m, cmd
 				}
+				m = m.commit("edit of "+result.Fields.Title, before, "password updated")
 			} else {
-				entry := models.NewPasswordEntry(result.Title, result.Username, result.Password, result.URL, result.Notes)
+				entry := models.NewPasswordEntry(result.Fields)
 				m.vault.AddEntry(entry)
-				m.listModel = m.listModel.SetStatus("password added")
+				m = m.commit("new entry "+entry.Title, before, "password added")
 			}
-
-			if err := m.storage.SaveVault(m.vault, m.masterPassword); err != nil {
-				m.listModel = m.listModel.SetStatus("failed to save vault")
-			}
-
-			m.listModel = m.listModel.UpdateEntries(m.vault.Entries)
-			m.state = StateList
 		}
 	}
 
@@ -235,28 +462,232 @@ func (m AppModel) handleConfirmDeleteState(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if keyMsg, ok := msg.(tea.KeyMsg); ok {
 		switch keyMsg.String() {
 		case "y", "Y":
-			if m.vault.DeleteEntry(m.pendingDeleteID) {
-				if err := m.storage.SaveVault(m.vault, m.masterPassword); err != nil {
-					m.listModel = m.listModel.SetStatus("failed to save vault")
+			before := m.vault.Snapshot()
+			if m.pendingFolderID != "" {
+				path := m.vault.FolderPath(m.pendingFolderID)
+				if err := m.vault.DeleteFolder(m.pendingFolderID); err != nil {
+					m.listModel = m.listModel.SetStatus("failed to delete folder: " + err.Error())
+					m.state = StateList
 				} else {
-					m.listModel = m.listModel.SetStatus("password deleted")
+					m = m.commit("delete of folder "+path, before, "folder deleted")
 				}
-				m.listModel = m.listModel.UpdateEntries(m.vault.Entries)
+				m.pendingFolderID = ""
+				break
+			}
+			entry, _ := m.vault.GetEntry(m.pendingDeleteID)
+			if m.vault.DeleteEntry(m.pendingDeleteID) {
+				m = m.commit("delete of "+entry.Title, before, "password moved to trash")
 			} else {
 				m.listModel = m.listModel.SetStatus("failed to delete password")
+				m.state = StateList
 			}
-			m.state = StateList
 			m.pendingDeleteID = ""
 
 		case "n", "N", "esc":
 			m.state = StateList
 			m.pendingDeleteID = ""
+			m.pendingFolderID = ""
 		}
 	}
 
 	return m, nil
 }
 
+// commit records what was done to the vault since before, so that it can be
+// undone, then saves the vault
+func (m AppModel) commit(what string, before models.Snapshot, status string) AppModel {
+	m.undo = m.undo.push(what, m.vault.ChangeSince(before))
+	return m.saveVault(status)
+}
+
+// undoChange takes back the latest change made this session and saves
+func (m AppModel) undoChange() (tea.Model, tea.Cmd) {
+	undo, step, ok := m.undo.undo()
+	if !ok {
+		m.listModel = m.listModel.SetStatus("nothing to undo")
+		return m, nil
+	}
+	if err := m.vault.Undo(step.change); err != nil {
+		m.listModel = m.listModel.SetStatus("cannot undo " + step.what + ": " + err.Error())
+		return m, nil
+	}
+	m.undo = undo
+	return m.saveVault("undid " + step.what), nil
+}
+
+// redoChange makes the latest undone change again and saves
+func (m AppModel) redoChange() (tea.Model, tea.Cmd) {
+	undo, step, ok := m.undo.redo()
+	if !ok {
+		m.listModel = m.listModel.SetStatus("nothing to redo")
+		return m, nil
+	}
+	if err := m.vault.Redo(step.change); err != nil {
+		m.listModel = m.listModel.SetStatus("cannot redo " + step.what + ": " + err.Error())
+		return m, nil
+	}
+	m.undo = undo
+	return m.saveVault("redid " + step.what), nil
+}
+
+// saveVault persists the vault and returns to the list with the given status.
+// If another process changed the vault meanwhile its changes are merged in,
+// and any conflicting edits are handed to the conflict resolution screen.
+func (m AppModel) saveVault(status string) AppModel {
+	err := m.storage.SaveVault(m.vault, m.session)
+
+	var conflictErr *storage.ConflictError
+	var syncErr *storage.SyncError
+	switch {
+	case err == nil:
+		m.listModel = m.listModel.SetStatus(status)
+		m.state = StateList
+	case errors.As(err, &syncErr):
+		m.listModel = m.listModel.SetStatus(status + "; warning: " + err.Error())
+		m.state = StateList
+	case errors.As(err, &conflictErr):
+		m.conflictsModel = NewConflictsModel(conflictErr.Conflicts)
+		m.state = StateConflicts
+	default:
+		m.listModel = m.listModel.SetStatus("failed to save vault: " + err.Error())
+		m.state = StateList
+	}
+
+	m.listModel = m.listModel.UpdateVault(m.vault)
+	return m
+}
+
+func (m AppModel) handleConflictsState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	model, cmd := m.conflictsModel.Update(msg)
+	m.conflictsModel = model.(ConflictsModel)
+
+	if result, ok := msg.(ConflictsResult); ok {
+		changed := false
+		for _, resolution := range result.Resolutions {
+			if resolution.UseRemote == resolution.Conflict.RemoteWins {
+				continue // Already what the merge chose
+			}
+			m.vault.ResolveConflict(resolution.Conflict, resolution.UseRemote)
+			changed = true
+		}
+
+		if changed {
+			m = m.saveVault("conflicts resolved")
+		} else {
+			m.listModel = m.listModel.SetStatus("merged changes from another process")
+			m.state = StateList
+		}
+	}
+
+	return m, cmd
+}
+
+func (m AppModel) handleChangePasswordState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	model, cmd := m.changePasswordModel.Update(msg)
+	m.changePasswordModel = model.(ChangePasswordModel)
+
+	if result, ok := msg.(ChangePasswordResult); ok {
+		if result.Cancelled {
+			m.state = StateList
+			return m, nil
+		}
+
+		vault, session, err := m.storage.ChangeMasterPassword(result.OldPassword, result.NewPassword, m.config.KDFParams())
+		if err != nil {
+			m.changePasswordModel = m.changePasswordModel.SetError("Failed to change password: " + err.Error())
+			return m, nil
+		}
+
+		m.session.Wipe()
+		m.vault = vault
+		m.session = session
+		m.listModel = m.listModel.UpdateVault(m.vault)
+
+		// Backups under the old password would still open with it
+		status := "master password changed"
+		if _, _, err := m.storage.RekeyBackups(result.OldPassword, session); err != nil {
+			status += "; warning: old backups kept: " + err.Error()
+		}
+		m.listModel = m.listModel.SetStatus(status)
+		m.state = StateList
+	}
+
+	return m, cmd
+}
+
+func (m AppModel) handleTrashState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	model, cmd := m.trashModel.Update(msg)
+	m.trashModel = model.(TrashModel)
+
+	if result, ok := msg.(TrashResult); ok {
+		if result.Action == "back" {
+			m.state = StateList
+			return m, cmd
+		}
+
+		before := m.vault.Snapshot()
+		var what, status string
+		switch result.Action {
+		case "restore":
+			if _, err := m.vault.RestoreEntry(result.EntryID); err != nil {
+				m.trashModel = m.trashModel.SetVault(m.vault, "failed to restore: "+err.Error())
+				return m, cmd
+			}
+			what, status = "restore of "+result.Title, result.Title+" restored"
+		case "purge":
+			m.vault.PurgeEntry(result.EntryID)
+			what, status = "purge of "+result.Title, result.Title+" deleted for good"
+		case "empty":
+			m.vault.EmptyTrash()
+			what, status = "emptying the trash", "trash emptied"
+		}
+
+		// Stay in the trash unless the save failed or needs attention
+		m = m.commit(what, before, status)
+		if m.state == StateList && m.listModel.status == status {
+			m.trashModel = m.trashModel.SetVault(m.vault, status)
+			m.state = StateTrash
+		}
+	}
+
+	return m, cmd
+}
+
+func (m AppModel) handleBackupsState(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	model, cmd := m.backupsModel.Update(msg)
+	m.backupsModel = model.(BackupsModel)
+
+	if result, ok := msg.(BackupsResult); ok {
+		if result.Cancelled {
+			m.state = StateList
+			return m, nil
+		}
+
+		message := "backup restored, unlock to continue"
+		var syncErr *storage.SyncError
+		if err := m.storage.RestoreBackup(*result.Backup); errors.As(err, &syncErr) {
+			message = "backup restored, unlock to continue; warning: " + err.Error()
+		} else if err != nil {
+			m.backupsModel = m.backupsModel.SetError("Failed to restore backup: " + err.Error())
+			return m, nil
+		}
+
+		// The restored generation may use a different password; unlock again
+		return m.lock(message)
+	}
+
+	return m, cmd
+}
+
+// Close wipes the session key; call it once the program has exited
+func (m AppModel) Close() {
+	m.session.Wipe()
+}
+
 func (m AppModel) View() string {
 	switch m.state {
 	case StateLogin:
@@ -269,11 +700,23 @@ func (m AppModel) View() string {
 		return m.formModel.View()
 	case StateConfirmDelete:
 		return m.renderConfirmDelete()
+	case StateChangePassword:
+		return m.changePasswordModel.View()
+	case StateBackups:
+		return m.backupsModel.View()
+	case StateConflicts:
+		return m.conflictsModel.View()
+	case StateTrash:
+		return m.trashModel.View()
 	}
 	return ""
 }
 
 func (m AppModel) renderConfirmDelete() string {
+	if m.pendingFolderID != "" {
+		return m.renderConfirmDeleteFolder()
+	}
+
 	entry, found := m.vault.GetEntry(m.pendingDeleteID)
 	if !found {
 		return "entry not found"
@@ -285,71 +728,145 @@ func (m AppModel) renderConfirmDelete() string {
 %s %s
 
 %s`, 
-		TitleStyle.Render("delete password?"),
+		TitleStyle.Render("move to trash?"),
 		AccentStyle.Render("title:"), entry.Title,
 		AccentStyle.Render("username:"), entry.Username,
+		HelpStyle.Render("T in the list opens the trash • y: move to trash • n: cancel"))
+}
+
+// renderConfirmDeleteFolder asks before deleting a folder, whose contents
+// are kept by moving them up a level
+func (m AppModel) renderConfirmDeleteFolder() string {
+	folder, found := m.vault.GetFolder(m.pendingFolderID)
+	if !found {
+		return "folder not found"
+	}
+
+	parent := m.vault.FolderPath(folder.ParentID)
+	if parent == "" {
+		parent = "the top level"
+	}
+
+	return fmt.Sprintf(`%s
+
+%s %s
+%s
+
+%s`,
+		TitleStyle.Render("delete folder?"),
+		AccentStyle.Render("folder:"), m.vault.FolderPath(folder.ID),
+		HelpStyle.Render("its entries and subfolders move to "+parent),
 		HelpStyle.Render("y: delete • n: cancel"))
 }
 
-func (m AppModel) copyToClipboard(text string) error {
-	// Try the go-clipboard library first (most reliable)
-	if err := clipboard.WriteAll(text); err == nil {
-		return nil
+// recordUse notes that an entry's secret is being used, so searches rank it
+// higher. Failing to record it is not worth reporting.
+func (m AppModel) recordUse(entryID string) AppModel {
+	m.usage, _ = m.storage.RecordUse(m.session, entryID, time.Now())
+	m.listModel = m.listModel.SetUsage(m.usage)
+	return m
+}
+
+// copySecret copies a secret and reports it in the status line, where
+// what names the secret
+func (m AppModel) copySecret(secret, what string) (AppModel, tea.Cmd) {
+	m, cmd, err := m.writeClipboard(secret)
+	if err != nil {
+		m.listModel = m.listModel.SetStatus("failed to copy " + what)
+		return m, nil
 	}
+	m.listModel = m.listModel.SetStatus(what + " copied")
+	return m, cmd
+}
 
-	// Fallback to system commands
-	return m.copyToClipboardSystem(text)
+// writeClipboard puts a secret on the clipboard and arranges for it to be
+// cleared after the configured delay. The clearing is done by a detached
+// helper so it still happens if the program exits first.
+func (m AppModel) writeClipboard(secret string) (AppModel, tea.Cmd, error) {
+	if err := clipboard.Write(secret); err != nil {
+		return m, nil, err
+	}
+	if m.clipboardClear <= 0 {
+		return m, nil, nil
+	}
+
+	// A new copy replaces the pending clear of the previous one
+	if m.clipboardHelper != nil {
+		m.clipboardHelper.Kill()
+	}
+	m.clipboardHelper, _ = clipboard.StartClearer(secret, m.clipboardClear)
+	m.clipboardFingerprint = clipboard.Fingerprint(secret)
+	m.clipboardAt = time.Now().Add(m.clipboardClear)
+	m.clipboardGen++
+
+	m, cmd := m.handleClipboardTick(clipboardTickMsg{gen: m.clipboardGen})
+	return m, cmd, nil
 }
 
-func (m AppModel) copyToClipboardSystem(text string) error {
-	var cmd *exec.Cmd
-
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("pbcopy")
-	case "linux":
-		// Try different clipboard utilities in order of preference
-		if _, err := exec.LookPath("wl-copy"); err == nil {
-			// Wayland
-			cmd = exec.Command("wl-copy")
-		} else if _, err := exec.LookPath("xclip"); err == nil {
-			// X11 with xclip
-			cmd = exec.Command("xclip", "-selection", "clipboard")
-		} else if _, err := exec.LookPath("xsel"); err == nil {
-			// X11 with xsel
-			cmd = exec.Command("xsel", "--clipboard", "--input")
-		} else {
-			return fmt.Errorf("no clipboard utility found (install xclip, xsel, or wl-clipboard)")
+// copyOTP copies the entry's current one-time code. A counter-based key is
+// advanced and the vault saved, so the same code is never handed out twice.
+func (m AppModel) copyOTP(entry *models.PasswordEntry) (AppModel, tea.Cmd) {
+	if entry.OTP == "" {
+		m.listModel = m.listModel.SetStatus("no one-time password for " + entry.Title)
+		return m, nil
+	}
+	key, err := otp.Parse(entry.OTP)
+	if err != nil {
+		m.listModel = m.listModel.SetStatus("failed to read one-time password: " + err.Error())
+		return m, nil
+	}
+
+	if key.Type != otp.TypeHOTP {
+		code, err := key.TOTP(time.Now())
+		if err != nil {
+			m.listModel = m.listModel.SetStatus("failed to generate code: " + err.Error())
+			return m, nil
 		}
-	case "windows":
-		cmd = exec.Command("clip")
-	default:
-		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
+		return m.copySecret(code, "one-time code")
 	}
 
-	// Set up the command to read from stdin
-	stdin, err := cmd.StdinPipe()
+	code, err := key.NextHOTP()
 	if err != nil {
-		return fmt.Errorf("failed to create stdin pipe: %w", err)
+		m.listModel = m.listModel.SetStatus("failed to generate code: " + err.Error())
+		return m, nil
 	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start clipboard command: %w", err)
+	// A generated code counts as used, so the counter is saved either way
+	m, cmd, copyErr := m.writeClipboard(code)
+	m.vault.AdvanceOTP(entry.ID, key.URI())
+
+	status := "one-time code copied"
+	if copyErr != nil {
+		status = "failed to copy one-time code"
 	}
+	return m.saveVault(status), cmd
+}
 
-	// Write the text to the clipboard command
-	if _, err := stdin.Write([]byte(text)); err != nil {
-		stdin.Close()
-		cmd.Wait()
-		return fmt.Errorf("failed to write to clipboard: %w", err)
+// handleClipboardTick counts down to the clipboard clear in the list status.
+// If the helper could not be started the clipboard is cleared from here.
+func (m AppModel) handleClipboardTick(msg clipboardTickMsg) (AppModel, tea.Cmd) {
+	if msg.gen != m.clipboardGen {
+		return m, nil
 	}
 
-	// Close stdin and wait for the command to complete
-	stdin.Close()
-	if err := cmd.Wait(); err != nil {
-		return fmt.Errorf("clipboard command failed: %w", err)
+	remaining := time.Until(m.clipboardAt)
+	if remaining <= 0 {
+		if m.clipboardHelper == nil {
+			clipboard.ClearIfUnchanged(m.clipboardFingerprint)
+		}
+		m.clipboardHelper = nil
+		m.clipboardFingerprint = ""
+		m.listModel = m.listModel.SetCountdown("")
+		return m, nil
 	}
 
-	return nil
-}
\ No newline at end of file
+	seconds := int(math.Ceil(remaining.Seconds()))
+	m.listModel = m.listModel.SetCountdown(fmt.Sprintf("clipboard clears in %ds", seconds))
+
+	// Tick again when the displayed second changes
+	next := remaining - time.Duration(seconds-1)*time.Second
+	gen := m.clipboardGen
+	return m, tea.Tick(next, func(time.Time) tea.Msg {
+		return clipboardTickMsg{gen: gen}
+	})
+}
@@ -0,0 +1,128 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/storage"
+)
+
+// BackupsModel represents the backup generations screen
+type BackupsModel struct {
+	backups    []storage.Backup
+	cursor     int
+	confirming bool
+	error      string
+}
+
+// BackupsResult represents the result of the backups screen
+type BackupsResult struct {
+	Backup    *storage.Backup
+	Cancelled bool
+}
+
+// NewBackupsModel creates a new backups model
+func NewBackupsModel(backups []storage.Backup) BackupsModel {
+	return BackupsModel{backups: backups}
+}
+
+func (m BackupsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m BackupsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.confirming {
+		switch keyMsg.String() {
+		case "y", "Y":
+			m.confirming = false
+			backup := m.backups[m.cursor]
+			return m, func() tea.Msg {
+				return BackupsResult{Backup: &backup}
+			}
+		case "n", "N", "esc":
+			m.confirming = false
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "esc", "backspace":
+		return m, func() tea.Msg {
+			return BackupsResult{Cancelled: true}
+		}
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.backups)-1 {
+			m.cursor++
+		}
+
+	case "enter", "r":
+		if len(m.backups) > 0 {
+			m.confirming = true
+		}
+	}
+
+	return m, nil
+}
+
+func (m BackupsModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("backups") + "\n\n")
+
+	if len(m.backups) == 0 {
+		s.WriteString(HelpStyle.Render("no backups yet"))
+		s.WriteString("\n\n")
+	}
+
+	for i, backup := range m.backups {
+		line := fmt.Sprintf("%2d  %s  %s", i+1,
+			backup.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+			HelpStyle.Render(fmt.Sprintf("%d bytes", backup.Size)))
+		if i == m.cursor {
+			s.WriteString(HighlightStyle.Render("> ") + line)
+		} else {
+			s.WriteString("  " + line)
+		}
+		s.WriteString("\n")
+	}
+
+	if m.error != "" {
+		s.WriteString("\n" + ErrorStyle.Render(m.error) + "\n")
+	}
+
+	s.WriteString("\n")
+	if m.confirming {
+		backup := m.backups[m.cursor]
+		s.WriteString(AccentStyle.Render("restore backup from "+backup.CreatedAt.Local().Format("2006-01-02 15:04:05")+"?") + "\n")
+		s.WriteString(HelpStyle.Render("the current vault is backed up first • y: restore • n: cancel"))
+	} else {
+		help := []string{
+			AccentStyle.Render("↑/↓") + ": select",
+			AccentStyle.Render("enter") + ": restore",
+			AccentStyle.Render("esc") + ": back",
+		}
+		s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+	}
+
+	return s.String()
+}
+
+func (m BackupsModel) SetError(err string) BackupsModel {
+	m.error = err
+	return m
+}
@@ -0,0 +1,65 @@
+package ui_test
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/models/modelstest"
+	"vault/internal/ui"
+)
+
+const benchQuery = "github adm"
+
+// BenchmarkShowVault shows a large vault's folder tree in the list, as
+// unlocking and saving do
+func BenchmarkShowVault(b *testing.B) {
+	vault := modelstest.BenchVault()
+	b.ResetTimer()
+	for range b.N {
+		ui.NewListModel(nil).UpdateVault(vault)
+	}
+}
+
+// BenchmarkFilter opens the list filter on a large vault and types a query
+// into it one key at a time
+func BenchmarkFilter(b *testing.B) {
+	shown := ui.NewListModel(nil).UpdateVault(modelstest.BenchVault())
+	b.ResetTimer()
+	for range b.N {
+		m := press(shown, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}}, false)
+		for _, r := range benchQuery {
+			m = press(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}, true)
+		}
+	}
+}
+
+// press sends a key to the list and, if it changes the query, waits for
+// the filter results it asks for, leaving aside the cursor blinking
+func press(m ui.ListModel, key tea.KeyMsg, filters bool) ui.ListModel {
+	model, cmd := m.Update(key)
+	m = model.(ui.ListModel)
+	if !filters {
+		return m
+	}
+
+	results := make(chan tea.Msg, 1)
+	var run func(cmd tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			for _, cmd := range msg {
+				go run(cmd)
+			}
+		case list.FilterMatchesMsg:
+			results <- msg
+		}
+	}
+	go run(cmd)
+
+	model, _ = m.Update(<-results)
+	return model.(ui.ListModel)
+}
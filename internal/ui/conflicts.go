@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/models"
+)
+
+// ConflictsModel walks the user through entries edited both here and by
+// another process, one conflict at a time
+type ConflictsModel struct {
+	conflicts   []models.Conflict
+	index       int
+	resolutions []ConflictResolution
+}
+
+// ConflictResolution records which side of a conflict the user kept
+type ConflictResolution struct {
+	Conflict  models.Conflict
+	UseRemote bool
+}
+
+// ConflictsResult represents the user's decisions; conflicts without a
+// resolution keep the automatic choice
+type ConflictsResult struct {
+	Resolutions []ConflictResolution
+}
+
+// NewConflictsModel creates a new conflicts model
+func NewConflictsModel(conflicts []models.Conflict) ConflictsModel {
+	return ConflictsModel{conflicts: conflicts}
+}
+
+func (m ConflictsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ConflictsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "m":
+		return m.resolve(false)
+
+	case "t":
+		return m.resolve(true)
+
+	case "enter":
+		return m.resolve(m.conflicts[m.index].RemoteWins)
+
+	case "esc":
+		return m, m.done()
+	}
+
+	return m, nil
+}
+
+// resolve records a decision for the current conflict and moves on
+func (m ConflictsModel) resolve(useRemote bool) (tea.Model, tea.Cmd) {
+	m.resolutions = append(m.resolutions, ConflictResolution{
+		Conflict:  m.conflicts[m.index],
+		UseRemote: useRemote,
+	})
+
+	m.index++
+	if m.index >= len(m.conflicts) {
+		m.index = len(m.conflicts) - 1
+		return m, m.done()
+	}
+	return m, nil
+}
+
+func (m ConflictsModel) done() tea.Cmd {
+	resolutions := m.resolutions
+	return func() tea.Msg {
+		return ConflictsResult{Resolutions: resolutions}
+	}
+}
+
+func (m ConflictsModel) View() string {
+	var s strings.Builder
+
+	c := m.conflicts[m.index]
+
+	s.WriteString(TitleStyle.Render("conflict") + " ")
+	s.WriteString(HelpStyle.Render(fmt.Sprintf("%d of %d", m.index+1, len(m.conflicts))) + "\n\n")
+	s.WriteString("this entry was changed here and by another process\n\n")
+
+	s.WriteString(m.renderSide("mine", c.Local, c.Remote, !c.RemoteWins))
+	s.WriteString("\n")
+	s.WriteString(m.renderSide("theirs", c.Remote, c.Local, c.RemoteWins))
+	s.WriteString("\n")
+
+	help := []string{
+		AccentStyle.Render("m") + ": keep mine",
+		AccentStyle.Render("t") + ": keep theirs",
+		AccentStyle.Render("enter") + ": keep current",
+		AccentStyle.Render("esc") + ": keep current for all",
+	}
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
+
+// renderSide shows one version of the entry, marking fields that differ
+func (m ConflictsModel) renderSide(label string, entry, other *models.PasswordEntry, kept bool) string {
+	var s strings.Builder
+
+	heading := AccentStyle.Render(label + ":")
+	if kept {
+		heading += " " + SuccessStyle.Render("(current)")
+	}
+	s.WriteString(heading + "\n")
+
+	if entry == nil {
+		s.WriteString("  " + HelpStyle.Render("deleted (in the trash)") + "\n")
+		return s.String()
+	}
+
+	var o models.PasswordEntry
+	if other != nil {
+		o = *other
+	}
+
+	field := func(name, value string, differs bool) {
+		line := fmt.Sprintf("  %-9s %s", name, value)
+		if other != nil && differs {
+			line = HighlightStyle.Render(line)
+		}
+		s.WriteString(line + "\n")
+	}
+
+	passwordDiffers := entry.Password != o.Password
+	password := strings.Repeat("•", 8)
+	if other != nil && passwordDiffers {
+		password += " (differs)"
+	}
+
+	field("title", entry.Title, entry.Title != o.Title)
+	if entry.Kind != o.Kind || len(entry.Details) > 0 || len(o.Details) > 0 {
+		// The summary stands in for the details, which may be secret
+		field("kind", entry.Schema().Label+": "+entry.Summary(), entry.Kind != o.Kind || !maps.Equal(entry.Details, o.Details))
+	}
+	field("username", entry.Username, entry.Username != o.Username)
+	field("password", password, passwordDiffers)
+	field("url", entry.URL, entry.URL != o.URL)
+	field("notes", entry.Notes, entry.Notes != o.Notes)
+	if len(entry.Tags) > 0 || len(o.Tags) > 0 {
+		field("tags", strings.Join(entry.Tags, ", "), !slices.Equal(entry.Tags, o.Tags))
+	}
+	if len(entry.CustomFields) > 0 || len(o.CustomFields) > 0 {
+		field("fields", fieldNames(entry.CustomFields), !slices.Equal(entry.CustomFields, o.CustomFields))
+	}
+	s.WriteString("  " + HelpStyle.Render("updated "+entry.UpdatedAt.Format("2006-01-02 15:04:05")) + "\n")
+
+	return s.String()
+}
+
+// fieldNames lists custom field names without their possibly secret values
+func fieldNames(fields []models.CustomField) string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.Name
+	}
+	return strings.Join(names, ", ")
+}
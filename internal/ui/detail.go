@@ -3,36 +3,123 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"vault/internal/models"
+	"vault/internal/otp"
 )
 
+// otpBarWidth is the width of the TOTP countdown bar in cells
+const otpBarWidth = 20
+
 // DetailModel represents the password detail view
 type DetailModel struct {
 	entry          models.PasswordEntry
-	showPassword   bool
 	width          int
 	height         int
+	otpKey         *otp.Key // Parsed one-time password key, nil if none
+	otpError       string
+	rows           []detailRow  // The kind's fields, then custom fields, then notes
+	selected       int          // Index into rows
+	revealed       map[int]bool // Rows shown despite being concealed
+
+	// The history pane lists the entry's previous values in place of its fields
+	history         bool
+	historySelected int          // Index into the entry's history
+	historyRevealed map[int]bool // Previous values shown despite being concealed
+}
+
+// detailRow is a field of the entry that can be selected and copied
+type detailRow struct {
+	key       string // Schema key; empty for a custom field
+	label     string
+	value     string
+	shown     string // Value as displayed, if it differs
+	concealed bool
 }
 
+// otpTickMsg redraws the live one-time code
+type otpTickMsg struct{}
+
 // DetailResult represents actions from the detail view
 type DetailResult struct {
-	Action    string // "back", "copy", "edit", "delete"
+	Action    string // "back", "copy", "otp", "edit", "delete", "restore", "lock"
 	EntryID   string
 	Entry     *models.PasswordEntry
+	Field     string // Label of the field to copy; empty for the password
+	Value     string // Value of that field
+	History   int    // Index into the entry's history of the value to restore
 }
 
 // NewDetailModel creates a new detail model
 func NewDetailModel(entry models.PasswordEntry) DetailModel {
-	return DetailModel{
-		entry:        entry,
-		showPassword: false,
+	m := DetailModel{
+		entry:    entry,
+		rows:     detailRows(&entry),
+		revealed: make(map[int]bool),
+
+		historyRevealed: make(map[int]bool),
+	}
+	// Start on the first secret, the password of a login
+	for i, row := range m.rows {
+		if row.concealed {
+			m.selected = i
+			break
+		}
+	}
+	if entry.OTP != "" {
+		key, err := otp.Parse(entry.OTP)
+		if err != nil {
+			m.otpError = err.Error()
+		} else {
+			m.otpKey = key
+		}
+	}
+	return m
+}
+
+// detailRows lists the fields the detail view shows. Empty optional fields
+// are left out; notes come last, as the longest.
+func detailRows(entry *models.PasswordEntry) []detailRow {
+	var rows []detailRow
+	var notes *detailRow
+	for _, field := range entry.Schema().Fields {
+		value := entry.Value(field.Key)
+		if value == "" && !field.Required {
+			continue
+		}
+		row := detailRow{key: field.Key, label: field.Label, value: value, concealed: field.Concealed()}
+		if entry.Schema().Kind == models.KindCard && field.Key == "number" {
+			row.shown = models.FormatCardNumber(value)
+		}
+		if field.Key == models.KeyNotes {
+			notes = &row
+			continue
+		}
+		rows = append(rows, row)
+	}
+	for _, field := range entry.CustomFields {
+		rows = append(rows, detailRow{label: field.Name, value: field.Value, concealed: field.Concealed})
 	}
+	if notes != nil {
+		rows = append(rows, *notes)
+	}
+	return rows
 }
 
 func (m DetailModel) Init() tea.Cmd {
-	return nil
+	return m.otpTick()
+}
+
+// otpTick schedules the next redraw of a time-based code, on the second
+func (m DetailModel) otpTick() tea.Cmd {
+	if m.otpKey == nil || m.otpKey.Type != otp.TypeTOTP {
+		return nil
+	}
+	return tea.Every(time.Second, func(time.Time) tea.Msg {
+		return otpTickMsg{}
+	})
 }
 
 func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -41,7 +128,14 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
+	case otpTickMsg:
+		return m, m.otpTick()
+
 	case tea.KeyMsg:
+		if m.history {
+			return m.updateHistory(msg)
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -51,15 +145,49 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return DetailResult{Action: "back"}
 			}
 
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+
+		case "down", "j":
+			if m.selected < len(m.rows)-1 {
+				m.selected++
+			}
+
 		case "enter", " ":
-			m.showPassword = !m.showPassword
+			m.revealed[m.selected] = !m.revealed[m.selected]
+
+		case "h":
+			m.history = true
 
 		case "c":
+			if len(m.rows) == 0 {
+				return m, nil
+			}
+			row := m.rows[m.selected]
+			field := row.label
+			if m.entry.Schema().Kind == models.KindLogin && row.key == models.KeyPassword {
+				field = "" // Reported as "password copied", as before
+			}
 			return m, func() tea.Msg {
 				return DetailResult{
 					Action:  "copy",
 					EntryID: m.entry.ID,
 					Entry:   &m.entry,
+					Field:   field,
+					Value:   row.value,
+				}
+			}
+
+		case "o":
+			if m.otpKey != nil {
+				return m, func() tea.Msg {
+					return DetailResult{
+						Action:  "otp",
+						EntryID: m.entry.ID,
+						Entry:   &m.entry,
+					}
 				}
 			}
 
@@ -80,39 +208,103 @@ func (m DetailModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Entry:   &m.entry,
 				}
 			}
+
+		case "L":
+			return m, func() tea.Msg {
+				return DetailResult{Action: "lock"}
+			}
 		}
 	}
 
 	return m, nil
 }
 
-func (m DetailModel) View() string {
-	var s strings.Builder
+// updateHistory handles keys while the history pane is open
+func (m DetailModel) updateHistory(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "esc", "backspace", "h":
+		m.history = false
 
-	s.WriteString(TitleStyle.Render(m.entry.Title) + "\n\n")
+	case "up", "k":
+		if m.historySelected > 0 {
+			m.historySelected--
+		}
 
-	// Entry details
-	if m.entry.Username != "" {
-		s.WriteString(AccentStyle.Render("username: ") + m.entry.Username + "\n")
+	case "down", "j":
+		if m.historySelected < len(m.entry.History)-1 {
+			m.historySelected++
+		}
+
+	case "enter", " ":
+		m.historyRevealed[m.historySelected] = !m.historyRevealed[m.historySelected]
+
+	case "c":
+		if len(m.entry.History) == 0 {
+			return m, nil
+		}
+		item := m.entry.History[m.historySelected]
+		return m, func() tea.Msg {
+			return DetailResult{
+				Action:  "copy",
+				EntryID: m.entry.ID,
+				Entry:   &m.entry,
+				Field:   "previous " + m.entry.HistoryLabel(item),
+				Value:   item.Value,
+			}
+		}
+
+	case "r":
+		if len(m.entry.History) == 0 {
+			return m, nil
+		}
+		return m, func() tea.Msg {
+			return DetailResult{
+				Action:  "restore",
+				EntryID: m.entry.ID,
+				Entry:   &m.entry,
+				History: m.historySelected,
+			}
+		}
+
+	case "L":
+		return m, func() tea.Msg {
+			return DetailResult{Action: "lock"}
+		}
 	}
 
-	if m.entry.URL != "" {
-		s.WriteString(AccentStyle.Render("url: ") + m.entry.URL + "\n")
+	return m, nil
+}
+
+func (m DetailModel) View() string {
+	if m.history {
+		return m.viewHistory()
 	}
 
-	// Password field with toggle
-	s.WriteString(AccentStyle.Render("password: "))
-	if m.showPassword {
-		s.WriteString(m.entry.Password)
-		s.WriteString(" " + HelpStyle.Render("(visible)"))
-	} else {
-		s.WriteString(strings.Repeat("•", len(m.entry.Password)))
-		s.WriteString(" " + HelpStyle.Render("(hidden)"))
+	var s strings.Builder
+
+	schema := m.entry.Schema()
+	s.WriteString(TitleStyle.Render(m.entry.Title) + "\n")
+	label := schema.Icon + " " + schema.Label
+	if m.entry.Favorite {
+		label += " · ★ favourite"
 	}
-	s.WriteString("\n")
+	s.WriteString(HelpStyle.Render(label) + "\n\n")
+
+	for i, row := range m.rows {
+		s.WriteString(m.marker(i) + AccentStyle.Render(row.label+": ") + m.renderRow(i) + "\n")
 
-	if m.entry.Notes != "" {
-		s.WriteString(AccentStyle.Render("notes: ") + m.entry.Notes + "\n")
+		// Derived lines follow the field they come from
+		switch {
+		case schema.Kind == models.KindLogin && row.key == models.KeyPassword && m.entry.OTP != "":
+			s.WriteString(m.pad() + AccentStyle.Render("one-time code: ") + m.renderOTP() + "\n")
+		case schema.Kind == models.KindSSHKey && row.key == "public_key":
+			if fingerprint := models.SSHFingerprint(row.value); fingerprint != "" {
+				s.WriteString(m.pad() + AccentStyle.Render("fingerprint: ") + fingerprint + "\n")
+			}
+		}
 	}
 
 	s.WriteString("\n")
@@ -127,17 +319,160 @@ func (m DetailModel) View() string {
 	help := []string{
 		AccentStyle.Render("enter") + ": toggle password",
 		AccentStyle.Render("c") + ": copy",
+	}
+	if len(m.rows) > 1 {
+		help = []string{
+			AccentStyle.Render("↑/↓") + ": select field",
+			AccentStyle.Render("enter") + ": reveal",
+			AccentStyle.Render("c") + ": copy",
+		}
+	}
+	if m.otpKey != nil {
+		help = append(help, AccentStyle.Render("o")+": copy code")
+	}
+	if len(m.entry.History) > 0 {
+		help = append(help, AccentStyle.Render("h")+": history")
+	}
+	help = append(help,
 		AccentStyle.Render("e") + ": edit",
 		AccentStyle.Render("d") + ": delete",
+		AccentStyle.Render("L") + ": lock",
 		AccentStyle.Render("esc") + ": back",
+	)
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
+
+// viewHistory lists the entry's previous values, newest first
+func (m DetailModel) viewHistory() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(m.entry.Title) + "\n")
+	s.WriteString(HelpStyle.Render("history") + "\n\n")
+
+	if len(m.entry.History) == 0 {
+		s.WriteString(HelpStyle.Render("no previous values yet; edits keep the values they replace here") + "\n")
+	}
+	for i, item := range m.entry.History {
+		marker := "  "
+		if i == m.historySelected {
+			marker = HighlightStyle.Render("› ")
+		}
+		s.WriteString(marker + HelpStyle.Render(item.ChangedAt.Format("2006-01-02 15:04")+"  ") +
+			AccentStyle.Render(m.entry.HistoryLabel(item)+": ") +
+			renderValue(item.Value, "", item.Concealed, m.historyRevealed[i]) + "\n")
+	}
+	s.WriteString("\n")
+
+	var help []string
+	if len(m.entry.History) > 0 {
+		help = append(help,
+			AccentStyle.Render("↑/↓")+": select",
+			AccentStyle.Render("enter")+": reveal",
+			AccentStyle.Render("c")+": copy",
+			AccentStyle.Render("r")+": restore",
+		)
 	}
+	help = append(help,
+		AccentStyle.Render("L")+": lock",
+		AccentStyle.Render("esc")+": back to details",
+	)
 	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
 
 	return s.String()
 }
 
-// TogglePassword toggles password visibility
+// marker points at the selected row once there is more than one field to
+// choose from
+func (m DetailModel) marker(row int) string {
+	if len(m.rows) <= 1 {
+		return ""
+	}
+	if row == m.selected {
+		return HighlightStyle.Render("› ")
+	}
+	return "  "
+}
+
+// pad lines up a line that is not a row with the rows
+func (m DetailModel) pad() string {
+	if len(m.rows) <= 1 {
+		return ""
+	}
+	return "  "
+}
+
+// maskWidth caps the dots standing in for a concealed value, so long keys
+// and notes do not fill the screen
+const maskWidth = 24
+
+// renderRow shows a row's value, masked while concealed. Values spanning
+// several lines start on a line of their own.
+func (m DetailModel) renderRow(i int) string {
+	row := m.rows[i]
+	return renderValue(row.value, row.shown, row.concealed, m.revealed[i])
+}
+
+// renderValue shows a value, or shown in its place if set, masked while
+// concealed and not revealed
+func renderValue(value, shown string, concealed, revealed bool) string {
+	display := value
+	if shown != "" {
+		display = shown
+	}
+	if strings.Contains(display, "\n") {
+		display = "\n" + strings.TrimRight(display, "\n")
+	}
+
+	switch {
+	case !concealed:
+		return display
+	case revealed:
+		return display + " " + HelpStyle.Render("(visible)")
+	default:
+		return strings.Repeat("•", min(len(value), maskWidth)) + " " + HelpStyle.Render("(hidden)")
+	}
+}
+
+// renderOTP shows the live code with a countdown bar, or for counter-based
+// keys a hint, since showing a code would use it up
+func (m DetailModel) renderOTP() string {
+	if m.otpKey == nil {
+		return ErrorStyle.Render(m.otpError)
+	}
+	if m.otpKey.Type == otp.TypeHOTP {
+		return HelpStyle.Render(fmt.Sprintf("counter %d, press o for the next code", m.otpKey.Counter))
+	}
+
+	now := time.Now()
+	code, err := m.otpKey.TOTP(now)
+	if err != nil {
+		return ErrorStyle.Render(err.Error())
+	}
+
+	remaining := m.otpKey.Remaining(now)
+	period := time.Duration(m.otpKey.Period) * time.Second
+	filled := int(int64(otpBarWidth) * int64(remaining) / int64(period))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", otpBarWidth-filled)
+
+	barStyle := SuccessStyle
+	if remaining <= 5*time.Second {
+		barStyle = ErrorStyle
+	}
+	return HighlightStyle.Render(formatCode(code)) + "  " + barStyle.Render(bar) + " " +
+		HelpStyle.Render(fmt.Sprintf("%ds", int(remaining.Round(time.Second)/time.Second)))
+}
+
+// formatCode splits a code in two halves for reading aloud or typing
+func formatCode(code string) string {
+	half := len(code) / 2
+	return code[:half] + " " + code[half:]
+}
+
+// TogglePassword toggles the visibility of the selected field, the
+// password unless another was selected
 func (m DetailModel) TogglePassword() DetailModel {
-	m.showPassword = !m.showPassword
+	m.revealed[m.selected] = !m.revealed[m.selected]
 	return m
 }
\ No newline at end of file
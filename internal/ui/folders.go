@@ -0,0 +1,215 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/models"
+)
+
+// FolderItem represents a folder row in the list's tree
+type FolderItem struct {
+	folder   models.Folder
+	depth    int
+	expanded bool
+	count    int // Entries in the folder and its subfolders
+}
+
+func (i FolderItem) FilterValue() string {
+	return i.folder.Name
+}
+
+func (i FolderItem) Title() string {
+	marker := "▸ "
+	if i.expanded {
+		marker = "▾ "
+	}
+	return indent(i.depth) + marker + i.folder.Name + models.PathSeparator
+}
+
+func (i FolderItem) Description() string {
+	if i.count == 1 {
+		return indent(i.depth) + "  1 entry"
+	}
+	return indent(i.depth) + fmt.Sprintf("  %d entries", i.count)
+}
+
+// indent pads a tree row to its depth
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// FolderPickerModel lets the user choose the folder to move an entry to
+type FolderPickerModel struct {
+	ids    []string // Empty first row is the top level
+	paths  []string
+	cursor int
+}
+
+// FolderPickerResult carries the chosen folder back to the list; an empty
+// ID is the top level
+type FolderPickerResult struct {
+	FolderID  string
+	Cancelled bool
+}
+
+// NewFolderPickerModel lists every folder as a path, starting at current
+func NewFolderPickerModel(vault *models.Vault, current string) FolderPickerModel {
+	m := FolderPickerModel{ids: []string{""}, paths: []string{"(top level)"}}
+	m = m.appendFolders(vault, "")
+	for i, id := range m.ids {
+		if id == current {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+// appendFolders adds the folders inside parentID depth first
+func (m FolderPickerModel) appendFolders(vault *models.Vault, parentID string) FolderPickerModel {
+	for _, folder := range vault.Subfolders(parentID) {
+		m.ids = append(m.ids, folder.ID)
+		m.paths = append(m.paths, vault.FolderPath(folder.ID))
+		m = m.appendFolders(vault, folder.ID)
+	}
+	return m
+}
+
+func (m FolderPickerModel) Update(msg tea.Msg) (FolderPickerModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.ids)-1 {
+			m.cursor++
+		}
+
+	case "enter":
+		id := m.ids[m.cursor]
+		return m, func() tea.Msg {
+			return FolderPickerResult{FolderID: id}
+		}
+
+	case "esc":
+		return m, func() tea.Msg {
+			return FolderPickerResult{Cancelled: true}
+		}
+	}
+
+	return m, nil
+}
+
+func (m FolderPickerModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("move to folder") + "\n\n")
+
+	if len(m.ids) == 1 {
+		s.WriteString(HelpStyle.Render("no folders yet; press F in the list to create one") + "\n\n")
+	}
+
+	for i, path := range m.paths {
+		if i == m.cursor {
+			s.WriteString(HighlightStyle.Render("› ") + path + "\n")
+		} else {
+			s.WriteString("  " + path + "\n")
+		}
+	}
+	s.WriteString("\n")
+
+	help := []string{
+		AccentStyle.Render("↑/↓") + ": select",
+		AccentStyle.Render("enter") + ": move",
+		AccentStyle.Render("esc") + ": cancel",
+	}
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
+
+// FolderNameModel prompts for the name of a new or renamed folder
+type FolderNameModel struct {
+	title string
+	input textinput.Model
+	error string
+}
+
+// FolderNameResult carries the entered name back to the list
+type FolderNameResult struct {
+	Name      string
+	Cancelled bool
+}
+
+// NewFolderNameModel creates a prompt with the given title, filled in with
+// the current name when renaming
+func NewFolderNameModel(title, name string) FolderNameModel {
+	input := textinput.New()
+	input.Placeholder = "folder name"
+	input.CharLimit = 100
+	input.Width = 40
+	input.SetValue(name)
+	input.Focus()
+
+	return FolderNameModel{title: title, input: input}
+}
+
+func (m FolderNameModel) Update(msg tea.Msg) (FolderNameModel, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			return m, func() tea.Msg {
+				return FolderNameResult{Cancelled: true}
+			}
+
+		case "enter":
+			name := strings.TrimSpace(m.input.Value())
+			if err := models.ValidateFolderName(name); err != nil {
+				m.error = err.Error()
+				return m, nil
+			}
+			return m, func() tea.Msg {
+				return FolderNameResult{Name: name}
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m FolderNameModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render(m.title) + "\n\n")
+	s.WriteString(AccentStyle.Render("name:") + "\n")
+	s.WriteString(m.input.View() + "\n\n")
+
+	if m.error != "" {
+		s.WriteString(ErrorStyle.Render(m.error) + "\n\n")
+	}
+
+	help := []string{
+		AccentStyle.Render("enter") + ": save",
+		AccentStyle.Render("esc") + ": cancel",
+	}
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
@@ -1,12 +1,13 @@
 package ui
 
 import (
-	"crypto/rand"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"vault/internal/models"
+	"vault/internal/otp"
 )
 
 // FormModel represents the add/edit password form state
@@ -17,6 +18,20 @@ type FormModel struct {
 	entryID      string
 	error        string
 	showPassword bool
+	generator    *GeneratorModel // Open generator dialog, if any
+	kind         models.Kind
+	details      []models.KindField // The kind's fields kept in Details, whose inputs follow otpInput
+	fields       []fieldMeta        // Custom fields, whose inputs follow the details
+	tags         []string           // Tags in the vault, for completion
+	folderID     string          // Folder the entry is saved in
+	folderPath   string
+	favorite     bool // Kept as the entry had it; the form does not edit it
+}
+
+// fieldMeta holds what a custom field row has besides its two inputs
+type fieldMeta struct {
+	fieldType models.FieldType
+	concealed bool
 }
 
 // Form input indices
@@ -26,15 +41,18 @@ const (
 	passwordInput
 	urlInput
 	notesInput
+	tagsInput
+	otpInput
+	detailInput // First kind-specific detail, then the custom fields, each taking a name and a value input
 )
 
+// lineBreak stands in for line breaks in long values, which single-line
+// inputs cannot hold
+const lineBreak = "↵"
+
 // FormResult represents the result of form submission
 type FormResult struct {
-	Title     string
-	Username  string
-	Password  string
-	URL       string
-	Notes     string
+	Fields    models.EntryFields
 	IsEdit    bool
 	EntryID   string
 	Cancelled bool
@@ -43,13 +61,17 @@ type FormResult struct {
 // NewFormModel creates a new form model
 func NewFormModel(isEdit bool, entry *models.PasswordEntry) FormModel {
 	m := FormModel{
-		inputs:     make([]textinput.Model, 5),
+		inputs:     make([]textinput.Model, detailInput),
 		isEdit:     isEdit,
 		focusIndex: 0,
 	}
 
+	kind := models.KindLogin
 	if entry != nil {
 		m.entryID = entry.ID
+		m.folderID = entry.FolderID
+		m.favorite = entry.Favorite
+		kind = entry.Schema().Kind
 	}
 
 	// Initialize inputs
@@ -80,13 +102,32 @@ func NewFormModel(isEdit bool, entry *models.PasswordEntry) FormModel {
 	m.inputs[notesInput].CharLimit = 500
 	m.inputs[notesInput].Width = 40
 
+	m.inputs[tagsInput] = textinput.New()
+	m.inputs[tagsInput].Placeholder = "tags, separated by spaces (optional)"
+	m.inputs[tagsInput].CharLimit = 200
+	m.inputs[tagsInput].Width = 40
+	m.inputs[tagsInput].ShowSuggestions = true
+
+	m.inputs[otpInput] = textinput.New()
+	m.inputs[otpInput].Placeholder = "otpauth:// URI or base32 seed (optional)"
+	m.inputs[otpInput].EchoMode = textinput.EchoPassword
+	m.inputs[otpInput].EchoCharacter = '*'
+	m.inputs[otpInput].CharLimit = 500
+	m.inputs[otpInput].Width = 40
+
+	m = m.setKind(kind)
+
 	// Pre-fill form if editing
 	if isEdit && entry != nil {
 		m.inputs[titleInput].SetValue(entry.Title)
-		m.inputs[usernameInput].SetValue(entry.Username)
-		m.inputs[passwordInput].SetValue(entry.Password)
-		m.inputs[urlInput].SetValue(entry.URL)
-		m.inputs[notesInput].SetValue(entry.Notes)
+		for _, field := range entry.Schema().Fields {
+			m.inputs[m.inputIndex(field)].SetValue(toInput(field, entry.Value(field.Key)))
+		}
+		m.inputs[tagsInput].SetValue(strings.Join(entry.Tags, " "))
+		m.inputs[otpInput].SetValue(entry.OTP)
+		for _, field := range entry.CustomFields {
+			m = m.appendField(field)
+		}
 	}
 
 	// Focus first input
@@ -102,6 +143,19 @@ func (m FormModel) Init() tea.Cmd {
 func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if result, ok := msg.(GeneratorResult); ok {
+		m.generator = nil
+		if !result.Cancelled {
+			m.inputs[passwordInput].SetValue(result.Password)
+		}
+		return m, nil
+	}
+	if m.generator != nil {
+		generator, cmd := m.generator.Update(msg)
+		m.generator = &generator
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch msg.String() {
@@ -117,95 +171,206 @@ func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleSubmit()
 
 		case "ctrl+g":
-			return m.generatePassword()
+			if _, ok := models.SchemaFor(m.kind).Field(models.KeyPassword); !ok {
+				return m, nil
+			}
+			generator := NewGeneratorModel()
+			m.generator = &generator
+			return m, nil
 
 		case "ctrl+h":
 			// Toggle password visibility
 			m.showPassword = !m.showPassword
+			echo := textinput.EchoPassword
 			if m.showPassword {
-				m.inputs[passwordInput].EchoMode = textinput.EchoNormal
-			} else {
-				m.inputs[passwordInput].EchoMode = textinput.EchoPassword
+				echo = textinput.EchoNormal
+			}
+			m.inputs[passwordInput].EchoMode = echo
+			m.inputs[otpInput].EchoMode = echo
+			for i, field := range m.details {
+				if field.Concealed() {
+					m.inputs[detailInput+i].EchoMode = echo
+				}
+			}
+			for row := range m.fields {
+				m = m.updateFieldEcho(row)
 			}
 
-		case "tab", "shift+tab", "up", "down":
-			s := msg.String()
+		case "ctrl+k":
+			// An entry keeps its kind once saved, so nothing is dropped
+			if !m.isEdit {
+				m = m.setKind(nextKind(m.kind))
+			}
+			return m, nil
 
-			if s == "up" || s == "shift+tab" {
-				m.focusIndex--
-			} else {
-				m.focusIndex++
+		case "ctrl+n":
+			m = m.appendField(models.NewCustomField("", "", models.FieldText))
+			return m.focus(len(m.inputs) - 2), nil
+
+		case "ctrl+x":
+			if row, ok := m.focusedField(); ok {
+				return m.removeField(row), nil
 			}
+			return m, nil
 
-			if m.focusIndex > len(m.inputs)-1 {
-				m.focusIndex = 0
-			} else if m.focusIndex < 0 {
-				m.focusIndex = len(m.inputs) - 1
+		case "ctrl+t":
+			if row, ok := m.focusedField(); ok {
+				m.fields[row].fieldType = nextFieldType(m.fields[row].fieldType)
+				m.fields[row].concealed = m.fields[row].fieldType == models.FieldHidden
+				m = m.updateFieldEcho(row)
 			}
+			return m, nil
 
-			for i := 0; i < len(m.inputs); i++ {
-				if i == m.focusIndex {
-					m.inputs[i].Focus()
-				} else {
-					m.inputs[i].Blur()
+		case "ctrl+o":
+			if row, ok := m.focusedField(); ok {
+				m.fields[row].concealed = !m.fields[row].concealed
+				m = m.updateFieldEcho(row)
+			}
+			return m, nil
+
+		case "tab":
+			// Complete a partly typed tag before moving on
+			if m.focusIndex == tagsInput {
+				if suggestion := m.inputs[tagsInput].CurrentSuggestion(); suggestion != "" && suggestion != m.inputs[tagsInput].Value() {
+					m.inputs[tagsInput].SetValue(suggestion + " ")
+					m.inputs[tagsInput].CursorEnd()
+					return m.updateTagSuggestions(), nil
 				}
 			}
+			return m.step(1), nil
+
+		case "shift+tab", "up", "down":
+			s := msg.String()
+
+			if s == "up" || s == "shift+tab" {
+				return m.step(-1), nil
+			}
+			return m.step(1), nil
 
 		case "enter":
-			if m.focusIndex == len(m.inputs)-1 {
+			layout := m.layout()
+			if m.focusIndex == layout[len(layout)-1] {
 				return m.handleSubmit()
-			} else {
-				m.focusIndex++
-				if m.focusIndex > len(m.inputs)-1 {
-					m.focusIndex = 0
-				}
-				for i := 0; i < len(m.inputs); i++ {
-					if i == m.focusIndex {
-						m.inputs[i].Focus()
-					} else {
-						m.inputs[i].Blur()
-					}
-				}
 			}
+			return m.step(1), nil
 		}
 	}
 
 	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	if m.focusIndex == tagsInput {
+		m = m.updateTagSuggestions()
+	}
 	return m, cmd
 }
 
+// SetFolder sets the folder a new entry is created in, and its path for the
+// heading
+func (m FormModel) SetFolder(id, path string) FormModel {
+	m.folderID = id
+	m.folderPath = path
+	return m
+}
+
+// SetTags supplies the vault's tags for completion
+func (m FormModel) SetTags(tags []string) FormModel {
+	m.tags = tags
+	return m.updateTagSuggestions()
+}
+
+// updateTagSuggestions offers completions for the tag being typed. The input
+// matches suggestions against its whole value, so each one repeats the tags
+// typed before it.
+func (m FormModel) updateTagSuggestions() FormModel {
+	value := m.inputs[tagsInput].Value()
+	start := strings.LastIndexAny(value, ", ") + 1
+	typed, partial := value[:start], models.NormalizeTag(value[start:])
+
+	var suggestions []string
+	if partial != "" {
+		used := models.ParseTags(typed)
+		for _, tag := range m.tags {
+			if strings.HasPrefix(tag, partial) && !slices.Contains(used, tag) {
+				suggestions = append(suggestions, typed+tag)
+			}
+		}
+	}
+	m.inputs[tagsInput].SetSuggestions(suggestions)
+	return m
+}
+
 func (m FormModel) handleSubmit() (tea.Model, tea.Cmd) {
 	m.error = ""
 
+	schema := models.SchemaFor(m.kind)
 	title := strings.TrimSpace(m.inputs[titleInput].Value())
-	username := strings.TrimSpace(m.inputs[usernameInput].Value())
-	password := strings.TrimSpace(m.inputs[passwordInput].Value())
-	url := strings.TrimSpace(m.inputs[urlInput].Value())
-	notes := strings.TrimSpace(m.inputs[notesInput].Value())
+	tags := models.ParseTags(m.inputs[tagsInput].Value())
+	var otpKey string
+	if schema.OTP {
+		otpKey = strings.TrimSpace(m.inputs[otpInput].Value())
+	}
 
 	if title == "" {
 		m.error = "title required"
-		m.focusIndex = titleInput
-		return m, nil
+		return m.focus(titleInput), nil
 	}
 
-	if password == "" {
-		m.error = "password required"
-		m.focusIndex = passwordInput
-		return m, nil
+	// Only the kind's own fields are saved
+	fields := models.EntryFields{Kind: m.kind}
+	for _, field := range schema.Fields {
+		index := m.inputIndex(field)
+		value, err := field.Check(fromInput(field, m.inputs[index].Value()))
+		if err != nil {
+			m.error = err.Error()
+			return m.focus(index), nil
+		}
+		fields.SetValue(field.Key, value)
 	}
 
-	if url != "" && !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		url = "https://" + url
+	if otpKey != "" {
+		if _, err := otp.Parse(otpKey); err != nil {
+			m.error = err.Error()
+			return m.focus(otpInput), nil
+		}
+	}
+
+	var customFields []models.CustomField
+	for row, meta := range m.fields {
+		name := m.inputs[m.customInput()+2*row]
+		value := m.inputs[m.customInput()+2*row+1]
+		if strings.TrimSpace(name.Value()) == "" && strings.TrimSpace(value.Value()) == "" {
+			continue // Blank rows are dropped
+		}
+
+		field := models.CustomField{
+			Name:      name.Value(),
+			Value:     value.Value(),
+			Type:      meta.fieldType,
+			Concealed: meta.concealed,
+		}.Normalize()
+		if err := field.Validate(); err != nil {
+			m.error = err.Error()
+			if field.Name == "" {
+				return m.focus(m.customInput() + 2*row), nil
+			}
+			return m.focus(m.customInput() + 2*row + 1), nil
+		}
+		customFields = append(customFields, field)
+	}
+
+	fields.Title = title
+	fields.OTP = otpKey
+	fields.CustomFields = customFields
+	fields.Tags = tags
+	fields.FolderID = m.folderID
+	fields.Favorite = m.favorite
+	if err := fields.ApplySchema(); err != nil {
+		m.error = err.Error()
+		return m, nil
 	}
 
 	return m, func() tea.Msg {
 		return FormResult{
-			Title:     title,
-			Username:  username,
-			Password:  password,
-			URL:       url,
-			Notes:     notes,
+			Fields:    fields,
 			IsEdit:    m.isEdit,
 			EntryID:   m.entryID,
 			Cancelled: false,
@@ -213,35 +378,253 @@ func (m FormModel) handleSubmit() (tea.Model, tea.Cmd) {
 	}
 }
 
-func (m FormModel) generatePassword() (tea.Model, tea.Cmd) {
-	password := GenerateSecurePassword(16)
-	m.inputs[passwordInput].SetValue(password)
-	return m, nil
+// appendField adds the inputs for a custom field row
+func (m FormModel) appendField(field models.CustomField) FormModel {
+	name := textinput.New()
+	name.Placeholder = "field name"
+	name.CharLimit = 100
+	name.Width = 40
+	name.SetValue(field.Name)
+
+	value := textinput.New()
+	value.Placeholder = "value"
+	value.EchoCharacter = '*'
+	value.CharLimit = 500
+	value.Width = 40
+	value.SetValue(field.Value)
+
+	m.inputs = append(m.inputs, name, value)
+	m.fields = append(m.fields, fieldMeta{fieldType: field.Kind(), concealed: field.Concealed})
+	return m.updateFieldEcho(len(m.fields) - 1)
+}
+
+// removeField drops a custom field row and moves focus to the row's place
+func (m FormModel) removeField(row int) FormModel {
+	first := m.customInput() + 2*row
+	m.inputs = append(m.inputs[:first:first], m.inputs[first+2:]...)
+	m.fields = append(m.fields[:row:row], m.fields[row+1:]...)
+	return m.focus(min(first, len(m.inputs)-1))
+}
+
+// focusedField returns the custom field row holding the focus, if any
+func (m FormModel) focusedField() (int, bool) {
+	if m.focusIndex < m.customInput() {
+		return 0, false
+	}
+	return (m.focusIndex - m.customInput()) / 2, true
+}
+
+// updateFieldEcho masks a concealed field's value unless secrets are shown
+func (m FormModel) updateFieldEcho(row int) FormModel {
+	echo := textinput.EchoNormal
+	if m.fields[row].concealed && !m.showPassword {
+		echo = textinput.EchoPassword
+	}
+	m.inputs[m.customInput()+2*row+1].EchoMode = echo
+	return m
+}
+
+// focus moves the focus to the input at index
+func (m FormModel) focus(index int) FormModel {
+	m.focusIndex = index
+	for i := range m.inputs {
+		if i == index {
+			m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+	return m
+}
+
+// customInput is the index of the first custom field's name input
+func (m FormModel) customInput() int {
+	return detailInput + len(m.details)
+}
+
+// inputIndex returns the input holding a field of the kind's schema
+func (m FormModel) inputIndex(field models.KindField) int {
+	switch field.Key {
+	case models.KeyUsername:
+		return usernameInput
+	case models.KeyPassword:
+		return passwordInput
+	case models.KeyURL:
+		return urlInput
+	case models.KeyNotes:
+		return notesInput
+	}
+	for i, detail := range m.details {
+		if detail.Key == field.Key {
+			return detailInput + i
+		}
+	}
+	return titleInput
+}
+
+// layout lists the inputs the kind shows, in order; built-in inputs the
+// kind does not use stay hidden
+func (m FormModel) layout() []int {
+	schema := models.SchemaFor(m.kind)
+	layout := []int{titleInput}
+	for _, field := range schema.Fields {
+		layout = append(layout, m.inputIndex(field))
+	}
+	layout = append(layout, tagsInput)
+	if schema.OTP {
+		layout = append(layout, otpInput)
+	}
+	for i := m.customInput(); i < len(m.inputs); i++ {
+		layout = append(layout, i)
+	}
+	return layout
+}
+
+// step moves the focus by delta places through the layout, wrapping around
+func (m FormModel) step(delta int) FormModel {
+	layout := m.layout()
+	pos := 0
+	for i, index := range layout {
+		if index == m.focusIndex {
+			pos = i
+		}
+	}
+	return m.focus(layout[(pos+delta+len(layout))%len(layout)])
+}
+
+// setKind switches the form to a kind's layout. Details sharing a key with
+// the previous kind keep their values, as do the built-in inputs.
+func (m FormModel) setKind(kind models.Kind) FormModel {
+	schema := models.SchemaFor(kind)
+
+	previous := make(map[string]string, len(m.details))
+	for i, field := range m.details {
+		previous[field.Key] = m.inputs[detailInput+i].Value()
+	}
+	custom := m.inputs[m.customInput():]
+
+	m.kind = schema.Kind
+	m.details = nil
+	m.inputs = m.inputs[:detailInput:detailInput]
+	for _, field := range schema.Fields {
+		if !field.Builtin() {
+			input := textinput.New()
+			input.Width = 40
+			input.SetValue(previous[field.Key])
+			m.details = append(m.details, field)
+			m.inputs = append(m.inputs, input)
+		}
+	}
+	m.inputs = append(m.inputs, custom...)
+
+	for _, field := range schema.Fields {
+		input := &m.inputs[m.inputIndex(field)]
+		input.Placeholder = field.Label
+		if field.Placeholder != "" {
+			input.Placeholder = field.Placeholder
+		}
+		if !field.Required {
+			input.Placeholder += " (optional)"
+		}
+		input.CharLimit = 500
+		if field.Long {
+			input.CharLimit = 10000
+		}
+		input.EchoCharacter = '*'
+		input.EchoMode = textinput.EchoNormal
+		if field.Concealed() && !m.showPassword {
+			input.EchoMode = textinput.EchoPassword
+		}
+	}
+	return m.focus(titleInput)
+}
+
+// nextKind cycles through the entry kinds
+func nextKind(kind models.Kind) models.Kind {
+	for i, k := range models.Kinds {
+		if k == kind {
+			return models.Kinds[(i+1)%len(models.Kinds)]
+		}
+	}
+	return models.KindLogin
+}
+
+// toInput shows a value in a single-line input, marking its line breaks
+func toInput(field models.KindField, value string) string {
+	if field.Long {
+		return strings.ReplaceAll(value, "\n", lineBreak)
+	}
+	return value
+}
+
+// fromInput restores the line breaks toInput marked
+func fromInput(field models.KindField, value string) string {
+	if field.Long {
+		return strings.ReplaceAll(value, lineBreak, "\n")
+	}
+	return value
+}
+
+// nextFieldType cycles through the custom field types
+func nextFieldType(t models.FieldType) models.FieldType {
+	for i, fieldType := range models.FieldTypes {
+		if fieldType == t {
+			return models.FieldTypes[(i+1)%len(models.FieldTypes)]
+		}
+	}
+	return models.FieldText
 }
 
 func (m FormModel) View() string {
+	if m.generator != nil {
+		return m.generator.View()
+	}
+
 	var s strings.Builder
 
+	schema := models.SchemaFor(m.kind)
+	noun := schema.Label
+	if m.kind == models.KindLogin {
+		noun = "password"
+	}
 	if m.isEdit {
-		s.WriteString(TitleStyle.Render("edit password") + "\n\n")
+		s.WriteString(TitleStyle.Render("edit "+noun) + "\n\n")
 	} else {
-		s.WriteString(TitleStyle.Render("new password") + "\n\n")
+		s.WriteString(TitleStyle.Render("new "+noun) + "\n\n")
+		s.WriteString(AccentStyle.Render("kind:") + " " + schema.Icon + " " + schema.Label + " " + HelpStyle.Render("(ctrl+k to change)") + "\n\n")
+	}
+	if m.folderPath != "" {
+		s.WriteString(HelpStyle.Render("in "+m.folderPath+models.PathSeparator) + "\n\n")
 	}
 
-	fields := []string{"title", "username", "password", "url", "notes"}
-	for i, field := range fields {
-		s.WriteString(AccentStyle.Render(field + ":") + "\n")
-		
-		// Special handling for password field to show visibility indicator
-		if i == passwordInput {
-			s.WriteString(m.inputs[i].View())
-			if m.showPassword {
-				s.WriteString(" " + HelpStyle.Render("(visible)"))
-			} else {
-				s.WriteString(" " + HelpStyle.Render("(hidden)"))
-			}
-		} else {
-			s.WriteString(m.inputs[i].View())
+	s.WriteString(AccentStyle.Render("title:") + "\n" + m.inputs[titleInput].View() + "\n\n")
+	for _, field := range schema.Fields {
+		s.WriteString(AccentStyle.Render(field.Label+":") + "\n")
+		s.WriteString(m.inputs[m.inputIndex(field)].View())
+		if field.Concealed() {
+			s.WriteString(" " + m.visibility())
+		}
+		s.WriteString("\n\n")
+	}
+	s.WriteString(AccentStyle.Render("tags:") + "\n" + m.inputs[tagsInput].View() + "\n\n")
+	if schema.OTP {
+		s.WriteString(AccentStyle.Render("one-time password:") + "\n" + m.inputs[otpInput].View())
+		if m.inputs[otpInput].Value() != "" {
+			s.WriteString(" " + m.visibility())
+		}
+		s.WriteString("\n\n")
+	}
+
+	for row, meta := range m.fields {
+		label := "field (" + string(meta.fieldType)
+		if meta.concealed {
+			label += ", concealed"
+		}
+		s.WriteString(AccentStyle.Render(label+"):") + "\n")
+		s.WriteString(m.inputs[m.customInput()+2*row].View() + "\n")
+		s.WriteString(m.inputs[m.customInput()+2*row+1].View())
+		if meta.concealed {
+			s.WriteString(" " + m.visibility())
 		}
 		s.WriteString("\n\n")
 	}
@@ -251,36 +634,31 @@ func (m FormModel) View() string {
 		s.WriteString("\n\n")
 	}
 
-	help := AccentStyle.Render("ctrl+s") + ": save • " + AccentStyle.Render("ctrl+g") + ": generate • " + AccentStyle.Render("ctrl+h") + ": toggle password • " + AccentStyle.Render("tab") + ": next • " + AccentStyle.Render("esc") + ": cancel"
+	help := AccentStyle.Render("ctrl+s") + ": save • "
+	if _, ok := schema.Field(models.KeyPassword); ok {
+		help += AccentStyle.Render("ctrl+g") + ": generate • "
+	}
+	help += AccentStyle.Render("ctrl+h") + ": toggle secrets • " + AccentStyle.Render("ctrl+n") + ": add field • " + AccentStyle.Render("tab") + ": next • " + AccentStyle.Render("esc") + ": cancel"
+	if !m.isEdit {
+		help += " • " + AccentStyle.Render("ctrl+k") + ": kind"
+	}
+	if _, ok := m.focusedField(); ok {
+		help += "\n" + AccentStyle.Render("ctrl+t") + ": field type • " + AccentStyle.Render("ctrl+o") + ": conceal • " + AccentStyle.Render("ctrl+x") + ": remove field"
+	}
 	s.WriteString(HelpStyle.Render(help))
 
 	return s.String()
 }
 
+// visibility says whether secrets are currently shown
+func (m FormModel) visibility() string {
+	if m.showPassword {
+		return HelpStyle.Render("(visible)")
+	}
+	return HelpStyle.Render("(hidden)")
+}
+
 func (m FormModel) SetError(err string) FormModel {
 	m.error = err
 	return m
 }
-
-// GenerateSecurePassword generates a cryptographically secure password
-func GenerateSecurePassword(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()-_=+"
-	
-	if length < 8 {
-		length = 8
-	}
-	if length > 128 {
-		length = 128
-	}
-
-	password := make([]byte, length)
-	charsetLen := len(charset)
-
-	for i := range password {
-		randomBytes := make([]byte, 1)
-		rand.Read(randomBytes)
-		password[i] = charset[int(randomBytes[0])%charsetLen]
-	}
-
-	return string(password)
-}
\ No newline at end of file
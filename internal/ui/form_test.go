@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"testing"
+
+	"vault/internal/models"
+)
+
+// submit submits the form and returns what it sends back
+func submit(t *testing.T, m FormModel) FormResult {
+	t.Helper()
+	_, cmd := m.handleSubmit()
+	if cmd == nil {
+		t.Fatalf("form not submitted: %s", m.error)
+	}
+	result, ok := cmd().(FormResult)
+	if !ok {
+		t.Fatal("form did not send a FormResult")
+	}
+	return result
+}
+
+func TestFormEditKeepsFavorite(t *testing.T) {
+	for _, favorite := range []bool{true, false} {
+		entry := models.NewPasswordEntry(models.EntryFields{Title: "github", Password: "p", Favorite: favorite})
+		m := NewFormModel(true, entry)
+		m.inputs[titleInput].SetValue("github work")
+
+		result := submit(t, m)
+		if result.Fields.Favorite != favorite {
+			t.Errorf("editing an entry with favourite %v submitted favourite %v", favorite, result.Fields.Favorite)
+		}
+		if result.Fields.Title != "github work" {
+			t.Errorf("submitted title %q", result.Fields.Title)
+		}
+	}
+}
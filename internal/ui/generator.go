@@ -0,0 +1,187 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/generator"
+)
+
+// GeneratorModel is the password generator dialog opened from the form. It
+// shows a preview that is regenerated whenever an option changes.
+type GeneratorModel struct {
+	passphrase bool
+	options    generator.Options
+	phrase     generator.PassphraseOptions
+	preview    string
+	error      string
+}
+
+// GeneratorResult carries the accepted password, if any, back to the form
+type GeneratorResult struct {
+	Password  string
+	Cancelled bool
+}
+
+// NewGeneratorModel creates a generator dialog with the default options
+func NewGeneratorModel() GeneratorModel {
+	m := GeneratorModel{
+		options: generator.DefaultOptions(),
+		phrase:  generator.DefaultPassphraseOptions(),
+	}
+	return m.regenerate()
+}
+
+func (m GeneratorModel) Update(msg tea.Msg) (GeneratorModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		return m, func() tea.Msg {
+			return GeneratorResult{Cancelled: true}
+		}
+
+	case "enter":
+		if m.preview == "" {
+			return m, nil
+		}
+		password := m.preview
+		return m, func() tea.Msg {
+			return GeneratorResult{Password: password}
+		}
+
+	case "tab", "p":
+		m.passphrase = !m.passphrase
+
+	case "left", "-":
+		m = m.resize(-1)
+
+	case "right", "+", "=":
+		m = m.resize(1)
+
+	case "r", " ":
+		// Regenerate with the same options
+
+	case "l":
+		m.options.Lower = !m.options.Lower
+	case "u":
+		m.options.Upper = !m.options.Upper
+	case "d":
+		m.options.Digits = !m.options.Digits
+	case "s":
+		m.options.Symbols = !m.options.Symbols
+	case "a":
+		m.options.ExcludeAmbiguous = !m.options.ExcludeAmbiguous
+
+	case "c":
+		m.phrase.Capitalize = !m.phrase.Capitalize
+	case "n":
+		m.phrase.Number = !m.phrase.Number
+
+	default:
+		return m, nil
+	}
+
+	return m.regenerate(), nil
+}
+
+// resize changes the password length or word count within its bounds
+func (m GeneratorModel) resize(delta int) GeneratorModel {
+	if m.passphrase {
+		m.phrase.Words = clamp(m.phrase.Words+delta, generator.MinWords, generator.MaxWords)
+	} else {
+		m.options.Length = clamp(m.options.Length+delta, generator.MinLength, generator.MaxLength)
+	}
+	return m
+}
+
+func clamp(n, lo, hi int) int {
+	return max(lo, min(n, hi))
+}
+
+// regenerate replaces the preview using the current options
+func (m GeneratorModel) regenerate() GeneratorModel {
+	var err error
+	if m.passphrase {
+		m.preview, err = generator.Passphrase(m.phrase)
+	} else {
+		m.preview, err = generator.Password(m.options)
+	}
+
+	m.error = ""
+	if err != nil {
+		m.preview = ""
+		m.error = err.Error()
+	}
+	return m
+}
+
+func (m GeneratorModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("generate password") + "\n\n")
+
+	modes := []string{"password", "passphrase"}
+	for i, mode := range modes {
+		if (i == 1) == m.passphrase {
+			modes[i] = HighlightStyle.Render("[" + mode + "]")
+		} else {
+			modes[i] = HelpStyle.Render(" " + mode + " ")
+		}
+	}
+	s.WriteString(AccentStyle.Render("mode:") + " " + strings.Join(modes, " ") + "\n\n")
+
+	var help []string
+	if m.passphrase {
+		s.WriteString(AccentStyle.Render("words:") + fmt.Sprintf(" %d\n", m.phrase.Words))
+		s.WriteString(m.option("c", "capitalize words", m.phrase.Capitalize))
+		s.WriteString(m.option("n", "add a digit", m.phrase.Number))
+		help = append(help, AccentStyle.Render("←/→")+": words")
+	} else {
+		s.WriteString(AccentStyle.Render("length:") + fmt.Sprintf(" %d\n", m.options.Length))
+		s.WriteString(m.option("l", "lower case", m.options.Lower))
+		s.WriteString(m.option("u", "upper case", m.options.Upper))
+		s.WriteString(m.option("d", "digits", m.options.Digits))
+		s.WriteString(m.option("s", "symbols", m.options.Symbols))
+		s.WriteString(m.option("a", "exclude look-alikes ("+generator.AmbiguousChars+")", m.options.ExcludeAmbiguous))
+		help = append(help, AccentStyle.Render("←/→")+": length")
+	}
+	s.WriteString("\n")
+
+	if m.error != "" {
+		s.WriteString(ErrorStyle.Render(m.error) + "\n\n")
+	} else {
+		entropy := m.options.Entropy()
+		if m.passphrase {
+			entropy = m.phrase.Entropy()
+		}
+		s.WriteString(SuccessStyle.Render(m.preview) + "\n")
+		s.WriteString(HelpStyle.Render(fmt.Sprintf("about %d bits", int(entropy))) + "\n\n")
+	}
+
+	help = append(help,
+		AccentStyle.Render("tab")+": mode",
+		AccentStyle.Render("r")+": regenerate",
+		AccentStyle.Render("enter")+": use",
+		AccentStyle.Render("esc")+": cancel",
+	)
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
+
+// option renders a toggle with its key
+func (m GeneratorModel) option(key, label string, on bool) string {
+	box := "[ ]"
+	if on {
+		box = "[x]"
+	}
+	return fmt.Sprintf("%s %s %s\n", box, AccentStyle.Render(key), label)
+}
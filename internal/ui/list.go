@@ -1,41 +1,87 @@
 package ui
 
 import (
+	"io"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/paginator"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"vault/internal/models"
 )
 
 // ListItem represents a password entry in the list
 type ListItem struct {
-	entry models.PasswordEntry
+	entry   models.PasswordEntry
+	text    *models.SearchText // What the filter searches
+	depth   int                // Nesting in the folder tree
+	folder  string             // Folder path, shown when the list is flat
+	matched []string           // Fields a search found its words in, while rendering
 }
 
+// FilterValue is only the title: queryFilter searches the item's text
+// instead, which is built once rather than on every key typed
 func (i ListItem) FilterValue() string {
-	return i.entry.Title + " " + i.entry.Username + " " + i.entry.URL + " " + i.entry.Notes
+	return i.entry.Title
 }
 
+// Title is the entry's title, starred if it is a favourite. The star comes
+// last so that search matches highlighted in the title keep their place.
 func (i ListItem) Title() string {
-	return i.entry.Title
+	if i.entry.Favorite {
+		return indent(i.depth) + i.entry.Title + " ★"
+	}
+	return indent(i.depth) + i.entry.Title
 }
 
 func (i ListItem) Description() string {
-	if i.entry.Username != "" {
-		return i.entry.Username
+	description := i.entry.Schema().Icon + " " + i.entry.Summary()
+	if len(i.entry.Tags) > 0 {
+		description += "  #" + strings.Join(i.entry.Tags, " #")
+	}
+	if i.folder != "" {
+		description = i.folder + models.PathSeparator + " · " + description
+	}
+	if len(i.matched) > 0 && !slices.Equal(i.matched, []string{"title"}) {
+		description += "  · found in " + strings.Join(i.matched, ", ")
 	}
-	if i.entry.URL != "" {
-		return i.entry.URL
+	return indent(i.depth) + description
+}
+
+// itemDelegate renders items like the default delegate, noting under each
+// entry where the search being typed found it
+type itemDelegate struct {
+	list.DefaultDelegate
+}
+
+func (d itemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	if entry, ok := item.(ListItem); ok && m.FilterState() != list.Unfiltered {
+		if query, err := models.ParseQuery(m.FilterValue()); err == nil {
+			entry.matched = query.Rank(entry.text, nil, time.Time{}).Fields
+			item = entry
+		}
 	}
-	return "no description"
+	d.DefaultDelegate.Render(w, m, index, item)
 }
 
 // ListModel represents the password list view state
 type ListModel struct {
-	list   list.Model
-	vault  *models.Vault
-	status string
+	list         list.Model
+	vault        *models.Vault
+	status       string
+	countdown    string             // Pending clipboard clear, shown under the status
+	tag          string             // Only entries with this tag are listed
+	tagPicker    *TagPickerModel    // Open tag picker, if any
+	expanded     map[string]bool    // Folders whose contents are shown
+	flat         bool               // Listing matches without the tree, while a tag or filter is active
+	folderPicker *FolderPickerModel // Open folder picker, if any
+	folderPrompt *FolderNameModel   // Open folder name prompt, if any
+	target       string             // Entry being moved, folder being renamed, or parent of a new folder
+	renaming     bool               // The folder prompt renames target rather than creating in it
+	usage        models.Usage       // When entries were last used, to rank search results
 }
 
 // ListAction represents actions that can be performed on the list
@@ -47,14 +93,28 @@ const (
 	ListActionEdit
 	ListActionDelete
 	ListActionCopy
+	ListActionCopyOTP
 	ListActionView
+	ListActionChangePassword
+	ListActionBackups
+	ListActionTrash
+	ListActionUndo
+	ListActionRedo
+	ListActionFavorite
+	ListActionLock
+	ListActionMove
+	ListActionNewFolder
+	ListActionRenameFolder
+	ListActionDeleteFolder
 )
 
 // ListResult represents the result of a list action
 type ListResult struct {
-	Action  ListAction
-	EntryID string
-	Entry   *models.PasswordEntry
+	Action   ListAction
+	EntryID  string
+	Entry    *models.PasswordEntry
+	FolderID string // Destination of a move, folder acted on, or parent of a new entry or folder
+	Name     string // Name of a new or renamed folder
 }
 
 // NewListModel creates a new list model
@@ -64,14 +124,15 @@ func NewListModel(entries []models.PasswordEntry) ListModel {
 		items[i] = ListItem{entry: entry}
 	}
 
-	l := list.New(items, list.NewDefaultDelegate(), 80, 24)
+	l := list.New(items, itemDelegate{list.NewDefaultDelegate()}, 80, 24)
 	l.Title = "vault"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(true)
 	l.SetShowHelp(false)
 
 	return ListModel{
-		list: l,
+		list:     l,
+		expanded: make(map[string]bool),
 	}
 }
 
@@ -82,12 +143,75 @@ func (m ListModel) Init() tea.Cmd {
 func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if result, ok := msg.(TagPickerResult); ok {
+		m.tagPicker = nil
+		if !result.Cancelled {
+			m.tag = result.Tag
+			m.list.ResetFilter()
+			m = m.UpdateVault(m.vault)
+		}
+		return m, nil
+	}
+	if m.tagPicker != nil {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			picker, cmd := m.tagPicker.Update(msg)
+			m.tagPicker = &picker
+			return m, cmd
+		}
+	}
+
+	if result, ok := msg.(FolderPickerResult); ok {
+		m.folderPicker = nil
+		if result.Cancelled {
+			return m, nil
+		}
+		m = m.expandTo(result.FolderID)
+		entryID := m.target
+		return m, func() tea.Msg {
+			return ListResult{Action: ListActionMove, EntryID: entryID, FolderID: result.FolderID}
+		}
+	}
+	if m.folderPicker != nil {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			picker, cmd := m.folderPicker.Update(msg)
+			m.folderPicker = &picker
+			return m, cmd
+		}
+	}
+
+	if result, ok := msg.(FolderNameResult); ok {
+		m.folderPrompt = nil
+		if result.Cancelled {
+			return m, nil
+		}
+		action := ListActionNewFolder
+		if m.renaming {
+			action = ListActionRenameFolder
+		} else {
+			m = m.expandTo(m.target)
+		}
+		folderID := m.target
+		return m, func() tea.Msg {
+			return ListResult{Action: action, FolderID: folderID, Name: result.Name}
+		}
+	}
+	if _, ok := msg.(tea.WindowSizeMsg); !ok && m.folderPrompt != nil {
+		prompt, cmd := m.folderPrompt.Update(msg)
+		m.folderPrompt = &prompt
+		return m, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.list.SetWidth(msg.Width)
 		m.list.SetHeight(msg.Height - 3) // Leave space for status
 
 	case tea.KeyMsg:
+		// While a query is typed, letters are part of it rather than commands
+		if m.list.FilterState() == list.Filtering && msg.String() != "esc" && msg.String() != "ctrl+c" {
+			break
+		}
+
 		switch msg.String() {
 		case "ctrl+c", "q":
 			return m, tea.Quit
@@ -96,10 +220,64 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Clear filter if active
 			if m.list.FilterState() == list.Filtering {
 				m.list.ResetFilter()
+				return m.refresh(), nil
+			}
+			if m.list.FilterState() == list.Unfiltered && m.tag != "" {
+				m.tag = ""
+				m = m.UpdateVault(m.vault)
 				return m, nil
 			}
 
+		case "t":
+			if m.list.FilterState() != list.Filtering {
+				picker := NewTagPickerModel(m.entries(), m.tag)
+				m.tagPicker = &picker
+				return m, nil
+			}
+
+		case "right", "l":
+			if item, ok := m.list.SelectedItem().(FolderItem); ok && m.list.FilterState() != list.Filtering {
+				m.expanded[item.folder.ID] = true
+				return m.refresh(), nil
+			}
+
+		case "left", "h":
+			if m.list.FilterState() != list.Filtering && !m.flat {
+				if folderID, ok := m.collapseTarget(); ok {
+					m.expanded[folderID] = false
+					return m.refresh().selectFolder(folderID), nil
+				}
+			}
+
+		case "m":
+			if item, ok := m.list.SelectedItem().(ListItem); ok && m.list.FilterState() != list.Filtering {
+				picker := NewFolderPickerModel(m.vault, item.entry.FolderID)
+				m.folderPicker = &picker
+				m.target = item.entry.ID
+				return m, nil
+			}
+
+		case "F":
+			if m.vault != nil && m.list.FilterState() != list.Filtering {
+				prompt := NewFolderNameModel("new folder", "")
+				m.folderPrompt = &prompt
+				m.target, m.renaming = m.currentFolder(), false
+				return m, textinput.Blink
+			}
+
+		case "R":
+			if item, ok := m.list.SelectedItem().(FolderItem); ok && m.list.FilterState() != list.Filtering {
+				prompt := NewFolderNameModel("rename folder", item.folder.Name)
+				m.folderPrompt = &prompt
+				m.target, m.renaming = item.folder.ID, true
+				return m, textinput.Blink
+			}
+
 		case "enter":
+			if item, ok := m.list.SelectedItem().(FolderItem); ok {
+				m.expanded[item.folder.ID] = !item.expanded
+				return m.refresh(), nil
+			}
 			if item, ok := m.list.SelectedItem().(ListItem); ok {
 				return m, func() tea.Msg {
 					return ListResult{
@@ -111,8 +289,9 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "n":
+			folderID := m.currentFolder()
 			return m, func() tea.Msg {
-				return ListResult{Action: ListActionAdd}
+				return ListResult{Action: ListActionAdd, FolderID: folderID}
 			}
 
 		case "e":
@@ -136,6 +315,11 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			}
+			if item, ok := m.list.SelectedItem().(FolderItem); ok {
+				return m, func() tea.Msg {
+					return ListResult{Action: ListActionDeleteFolder, FolderID: item.folder.ID}
+				}
+			}
 
 		case "c":
 			if item, ok := m.list.SelectedItem().(ListItem); ok {
@@ -147,14 +331,83 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			}
+
+		case "o":
+			if item, ok := m.list.SelectedItem().(ListItem); ok {
+				return m, func() tea.Msg {
+					return ListResult{
+						Action:  ListActionCopyOTP,
+						EntryID: item.entry.ID,
+						Entry:   &item.entry,
+					}
+				}
+			}
+
+		case "P":
+			return m, func() tea.Msg {
+				return ListResult{Action: ListActionChangePassword}
+			}
+
+		case "B":
+			return m, func() tea.Msg {
+				return ListResult{Action: ListActionBackups}
+			}
+
+		case "T":
+			return m, func() tea.Msg {
+				return ListResult{Action: ListActionTrash}
+			}
+
+		case "u":
+			if m.list.FilterState() != list.Filtering {
+				return m, func() tea.Msg {
+					return ListResult{Action: ListActionUndo}
+				}
+			}
+
+		case "*":
+			if item, ok := m.list.SelectedItem().(ListItem); ok {
+				return m, func() tea.Msg {
+					return ListResult{
+						Action:  ListActionFavorite,
+						EntryID: item.entry.ID,
+						Entry:   &item.entry,
+					}
+				}
+			}
+
+		case "ctrl+r":
+			return m, func() tea.Msg {
+				return ListResult{Action: ListActionRedo}
+			}
+
+		case "L":
+			return m, func() tea.Msg {
+				return ListResult{Action: ListActionLock}
+			}
 		}
 	}
 
 	m.list, cmd = m.list.Update(msg)
+
+	// Filtering searches every entry, so the tree is flattened meanwhile
+	if flat := m.tag != "" || m.list.FilterState() != list.Unfiltered; flat != m.flat {
+		m = m.refresh()
+	}
 	return m, cmd
 }
 
 func (m ListModel) View() string {
+	if m.tagPicker != nil {
+		return m.tagPicker.View()
+	}
+	if m.folderPicker != nil {
+		return m.folderPicker.View()
+	}
+	if m.folderPrompt != nil {
+		return m.folderPrompt.View()
+	}
+
 	var s strings.Builder
 
 	// Check if list is empty
@@ -167,6 +420,14 @@ func (m ListModel) View() string {
 		s.WriteString("\n")
 	}
 
+	// A query being typed may not parse yet
+	if m.list.FilterState() != list.Unfiltered {
+		if _, err := models.ParseQuery(m.list.FilterValue()); err != nil {
+			s.WriteString(ErrorStyle.Render("• " + err.Error()))
+			s.WriteString("\n")
+		}
+	}
+
 	// Status line
 	if m.status != "" {
 		if strings.Contains(m.status, "error") || strings.Contains(m.status, "failed") {
@@ -176,6 +437,10 @@ func (m ListModel) View() string {
 		}
 		s.WriteString("\n")
 	}
+	if m.countdown != "" {
+		s.WriteString(HelpStyle.Render("• " + m.countdown))
+		s.WriteString("\n")
+	}
 
 	// Help
 	help := []string{
@@ -184,7 +449,19 @@ func (m ListModel) View() string {
 		AccentStyle.Render("e") + ": edit", 
 		AccentStyle.Render("d") + ": delete",
 		AccentStyle.Render("c") + ": copy",
+		AccentStyle.Render("o") + ": copy code",
+		AccentStyle.Render("←/→") + ": fold",
+		AccentStyle.Render("*") + ": favourite",
+		AccentStyle.Render("m") + ": move",
+		AccentStyle.Render("F") + ": new folder",
+		AccentStyle.Render("R") + ": rename folder",
 		AccentStyle.Render("/") + ": filter",
+		AccentStyle.Render("t") + ": tags",
+		AccentStyle.Render("P") + ": master password",
+		AccentStyle.Render("B") + ": backups",
+		AccentStyle.Render("T") + ": trash",
+		AccentStyle.Render("u/ctrl+r") + ": undo/redo",
+		AccentStyle.Render("L") + ": lock",
 		AccentStyle.Render("esc") + ": clear filter",
 		AccentStyle.Render("q") + ": quit",
 	}
@@ -193,13 +470,190 @@ func (m ListModel) View() string {
 	return s.String()
 }
 
-// UpdateEntries updates the list with new entries
-func (m ListModel) UpdateEntries(entries []models.PasswordEntry) ListModel {
-	items := make([]list.Item, len(entries))
-	for i, entry := range entries {
-		items[i] = ListItem{entry: entry}
+// UpdateVault shows the vault's folders and entries, keeping only entries
+// with the selected tag. A nil vault empties the list.
+func (m ListModel) UpdateVault(vault *models.Vault) ListModel {
+	m.vault = vault
+
+	// A tag nobody uses any more would leave the list empty for no reason
+	if m.tag != "" && !slices.ContainsFunc(m.entries(), func(e models.PasswordEntry) bool { return e.HasTag(m.tag) }) {
+		m.tag = ""
+	}
+	return m.refresh()
+}
+
+// refresh rebuilds the items: the folder tree, or a flat list of entries
+// while a tag or filter is active
+func (m ListModel) refresh() ListModel {
+	m.flat = m.tag != "" || m.list.FilterState() != list.Unfiltered
+
+	var items []list.Item
+	switch {
+	case m.vault == nil:
+	case m.flat:
+		paths := make(map[string]string) // Folder paths by ID, each worked out once
+		for i := range m.vault.Entries {
+			entry := &m.vault.Entries[i]
+			if m.tag != "" && !entry.HasTag(m.tag) {
+				continue
+			}
+			path, ok := paths[entry.FolderID]
+			if !ok {
+				path = m.vault.FolderPath(entry.FolderID)
+				paths[entry.FolderID] = path
+			}
+			items = append(items, ListItem{entry: *entry, text: m.vault.SearchText(entry), folder: path})
+		}
+	default:
+		inFolder := make(map[string][]int) // Positions of the entries in each folder
+		for i, entry := range m.vault.Entries {
+			inFolder[entry.FolderID] = append(inFolder[entry.FolderID], i)
+		}
+		items = m.treeItems("", 0, inFolder)
+	}
+
+	var folders []models.Folder
+	if m.vault != nil {
+		folders = m.vault.Folders
+	}
+	m.list.Filter = queryFilter(items, folders, m.usage)
+
+	// A dot per page would not fit anyway, and takes long to draw for
+	// large vaults, so count pages instead
+	m.list.Paginator.Type = paginator.Dots
+	if len(items) > m.list.Width()*m.list.Paginator.PerPage {
+		m.list.Paginator.Type = paginator.Arabic
+	}
+	if cmd := m.list.SetItems(items); cmd != nil {
+		// Refilter now rather than leave the list empty until the command runs
+		m.list, _ = m.list.Update(cmd())
+	}
+
+	m.list.Title = "vault"
+	if m.tag != "" {
+		m.list.Title = "vault #" + m.tag
 	}
-	m.list.SetItems(items)
+	return m
+}
+
+// queryFilter filters the list by parsing the filter text as a search
+// query, which only entries can match, best match first with the title
+// runes it matched. Nothing matches a query that does not parse; the view
+// says why. The items' texts are gathered once here, not on every key.
+func queryFilter(items []list.Item, folders []models.Folder, usage models.Usage) list.FilterFunc {
+	var texts []*models.SearchText
+	var indexes []int // Index of the item each text belongs to
+	for i, item := range items {
+		if item, ok := item.(ListItem); ok {
+			texts = append(texts, item.text)
+			indexes = append(indexes, i)
+		}
+	}
+
+	return func(term string, _ []string) []list.Rank {
+		query, err := models.ParseQuery(term)
+		if err != nil {
+			return nil
+		}
+		results := query.Search(texts, folders, usage)
+		ranks := make([]list.Rank, len(results))
+		for i, result := range results {
+			ranks[i] = list.Rank{Index: indexes[result.Index], MatchedIndexes: result.Title}
+		}
+		return ranks
+	}
+}
+
+// treeItems lists the folders and entries inside parentID, descending into
+// expanded folders. inFolder holds the positions of each folder's entries.
+func (m ListModel) treeItems(parentID string, depth int, inFolder map[string][]int) []list.Item {
+	var items []list.Item
+	for _, folder := range m.vault.Subfolders(parentID) {
+		expanded := m.expanded[folder.ID]
+		items = append(items, FolderItem{folder: folder, depth: depth, expanded: expanded, count: m.countEntries(folder.ID, inFolder)})
+		if expanded {
+			items = append(items, m.treeItems(folder.ID, depth+1, inFolder)...)
+		}
+	}
+	for _, i := range inFolder[parentID] {
+		entry := &m.vault.Entries[i]
+		items = append(items, ListItem{entry: *entry, text: m.vault.SearchText(entry), depth: depth})
+	}
+	return items
+}
+
+// countEntries counts the entries in a folder and its subfolders
+func (m ListModel) countEntries(folderID string, inFolder map[string][]int) int {
+	count := len(inFolder[folderID])
+	for _, folder := range m.vault.Subfolders(folderID) {
+		count += m.countEntries(folder.ID, inFolder)
+	}
+	return count
+}
+
+// entries returns every entry, before the tag filter
+func (m ListModel) entries() []models.PasswordEntry {
+	if m.vault == nil {
+		return nil
+	}
+	return m.vault.Entries
+}
+
+// currentFolder is the selected folder, or the folder of the selected entry
+func (m ListModel) currentFolder() string {
+	switch item := m.list.SelectedItem().(type) {
+	case FolderItem:
+		return item.folder.ID
+	case ListItem:
+		return item.entry.FolderID
+	}
+	return ""
+}
+
+// collapseTarget is the folder "left" collapses: the selected folder if it
+// is open, otherwise the folder holding the selection
+func (m ListModel) collapseTarget() (string, bool) {
+	switch item := m.list.SelectedItem().(type) {
+	case FolderItem:
+		if item.expanded {
+			return item.folder.ID, true
+		}
+		return item.folder.ParentID, item.folder.ParentID != ""
+	case ListItem:
+		return item.entry.FolderID, item.entry.FolderID != ""
+	}
+	return "", false
+}
+
+// expandTo opens a folder and every folder above it, so what is put there
+// stays in view
+func (m ListModel) expandTo(folderID string) ListModel {
+	for folderID != "" {
+		folder, ok := m.vault.GetFolder(folderID)
+		if !ok {
+			break
+		}
+		m.expanded[folderID] = true
+		folderID = folder.ParentID
+	}
+	return m
+}
+
+// selectFolder moves the cursor to a folder's row
+func (m ListModel) selectFolder(folderID string) ListModel {
+	for i, item := range m.list.Items() {
+		if folder, ok := item.(FolderItem); ok && folder.folder.ID == folderID {
+			m.list.Select(i)
+			break
+		}
+	}
+	return m
+}
+
+// SetUsage sets when entries were last used, which ranks the results of
+// the next search
+func (m ListModel) SetUsage(usage models.Usage) ListModel {
+	m.usage = usage
 	return m
 }
 
@@ -209,6 +663,12 @@ func (m ListModel) SetStatus(status string) ListModel {
 	return m
 }
 
+// SetCountdown sets the clipboard countdown line; empty hides it
+func (m ListModel) SetCountdown(countdown string) ListModel {
+	m.countdown = countdown
+	return m
+}
+
 // ClearStatus clears the status message
 func (m ListModel) ClearStatus() ListModel {
 	m.status = ""
@@ -11,6 +11,7 @@ import (
 type LoginModel struct {
 	passwordInput textinput.Model
 	error         string
+	message       string
 	isNewVault    bool
 	confirmInput  textinput.Model
 	focusIndex    int
@@ -164,6 +165,9 @@ func (m LoginModel) View() string {
 	if m.error != "" {
 		s.WriteString("\n")
 		s.WriteString(ErrorStyle.Render(m.error))
+	} else if m.message != "" {
+		s.WriteString("\n")
+		s.WriteString(SuccessStyle.Render(m.message))
 	}
 
 	s.WriteString("\n\n")
@@ -181,4 +185,10 @@ func (m LoginModel) View() string {
 func (m LoginModel) SetError(err string) LoginModel {
 	m.error = err
 	return m
-}
\ No newline at end of file
+}
+
+// SetMessage sets an informational message shown until the next error
+func (m LoginModel) SetMessage(message string) LoginModel {
+	m.message = message
+	return m
+}
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChangePasswordModel represents the change master password screen
+type ChangePasswordModel struct {
+	inputs     []textinput.Model
+	focusIndex int
+	error      string
+}
+
+// Change password input indices
+const (
+	currentPasswordInput = iota
+	newPasswordInput
+	confirmPasswordInput
+)
+
+// ChangePasswordResult represents the result of the change password screen
+type ChangePasswordResult struct {
+	OldPassword string
+	NewPassword string
+	Cancelled   bool
+}
+
+// NewChangePasswordModel creates a new change password model
+func NewChangePasswordModel() ChangePasswordModel {
+	placeholders := []string{"current password", "new password", "confirm new password"}
+
+	m := ChangePasswordModel{
+		inputs: make([]textinput.Model, len(placeholders)),
+	}
+	for i, placeholder := range placeholders {
+		m.inputs[i] = textinput.New()
+		m.inputs[i].Placeholder = placeholder
+		m.inputs[i].EchoMode = textinput.EchoPassword
+		m.inputs[i].EchoCharacter = '*'
+	}
+	m.inputs[currentPasswordInput].Focus()
+
+	return m
+}
+
+func (m ChangePasswordModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m ChangePasswordModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			return m, func() tea.Msg {
+				return ChangePasswordResult{Cancelled: true}
+			}
+
+		case "enter":
+			if m.focusIndex == len(m.inputs)-1 {
+				return m.handleSubmit()
+			}
+			return m.focus(m.focusIndex + 1), nil
+
+		case "tab", "down":
+			return m.focus(m.focusIndex + 1), nil
+
+		case "shift+tab", "up":
+			return m.focus(m.focusIndex - 1), nil
+		}
+	}
+
+	m.inputs[m.focusIndex], cmd = m.inputs[m.focusIndex].Update(msg)
+	return m, cmd
+}
+
+// focus moves focus to the given input, wrapping around
+func (m ChangePasswordModel) focus(index int) ChangePasswordModel {
+	if index >= len(m.inputs) {
+		index = 0
+	} else if index < 0 {
+		index = len(m.inputs) - 1
+	}
+
+	m.focusIndex = index
+	for i := range m.inputs {
+		if i == index {
+			m.inputs[i].Focus()
+		} else {
+			m.inputs[i].Blur()
+		}
+	}
+	return m
+}
+
+func (m ChangePasswordModel) handleSubmit() (tea.Model, tea.Cmd) {
+	current := strings.TrimSpace(m.inputs[currentPasswordInput].Value())
+	password := strings.TrimSpace(m.inputs[newPasswordInput].Value())
+	confirm := strings.TrimSpace(m.inputs[confirmPasswordInput].Value())
+
+	switch {
+	case current == "":
+		m.error = "Current password cannot be empty"
+		return m.focus(currentPasswordInput), nil
+	case password == "":
+		m.error = "New password cannot be empty"
+		return m.focus(newPasswordInput), nil
+	case len(password) < 8:
+		m.error = "Password must be at least 8 characters"
+		return m.focus(newPasswordInput), nil
+	case password != confirm:
+		m.error = "Passwords do not match"
+		return m.focus(confirmPasswordInput), nil
+	case password == current:
+		m.error = "New password must differ from the current one"
+		return m.focus(newPasswordInput), nil
+	}
+
+	m.error = ""
+	return m, func() tea.Msg {
+		return ChangePasswordResult{
+			OldPassword: current,
+			NewPassword: password,
+		}
+	}
+}
+
+func (m ChangePasswordModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("vault") + " - change master password\n\n")
+
+	for _, input := range m.inputs {
+		s.WriteString(input.View())
+		s.WriteString("\n")
+	}
+
+	if m.error != "" {
+		s.WriteString("\n")
+		s.WriteString(ErrorStyle.Render(m.error))
+	}
+
+	s.WriteString("\n\n")
+	help := AccentStyle.Render("tab") + ": next • " + AccentStyle.Render("enter") + ": change • " + AccentStyle.Render("esc") + ": cancel"
+	s.WriteString(HelpStyle.Render(help))
+
+	return s.String()
+}
+
+func (m ChangePasswordModel) SetError(err string) ChangePasswordModel {
+	m.error = err
+	return m
+}
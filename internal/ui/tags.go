@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/models"
+)
+
+// TagPickerModel lets the user narrow the list to entries with one tag
+type TagPickerModel struct {
+	tags   []string // Empty first row means all entries
+	counts map[string]int
+	cursor int
+}
+
+// TagPickerResult carries the chosen tag back to the list; an empty tag
+// shows every entry
+type TagPickerResult struct {
+	Tag       string
+	Cancelled bool
+}
+
+// NewTagPickerModel lists the tags used by entries, starting at current
+func NewTagPickerModel(entries []models.PasswordEntry, current string) TagPickerModel {
+	m := TagPickerModel{
+		tags:   []string{""},
+		counts: map[string]int{"": len(entries)},
+	}
+	for _, entry := range entries {
+		for _, tag := range entry.Tags {
+			if m.counts[tag] == 0 {
+				m.tags = append(m.tags, tag)
+			}
+			m.counts[tag]++
+		}
+	}
+	// Entries keep their tags sorted, but the order across entries varies
+	slices.Sort(m.tags[1:])
+
+	for i, tag := range m.tags {
+		if tag == current {
+			m.cursor = i
+		}
+	}
+	return m
+}
+
+func (m TagPickerModel) Update(msg tea.Msg) (TagPickerModel, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.tags)-1 {
+			m.cursor++
+		}
+
+	case "enter":
+		tag := m.tags[m.cursor]
+		return m, func() tea.Msg {
+			return TagPickerResult{Tag: tag}
+		}
+
+	case "esc", "t":
+		return m, func() tea.Msg {
+			return TagPickerResult{Cancelled: true}
+		}
+	}
+
+	return m, nil
+}
+
+func (m TagPickerModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("tags") + "\n\n")
+
+	if len(m.tags) == 1 {
+		s.WriteString(HelpStyle.Render("no tags yet; add them when editing an entry") + "\n\n")
+	}
+
+	for i, tag := range m.tags {
+		label := "#" + tag
+		if tag == "" {
+			label = "all entries"
+		}
+		line := fmt.Sprintf("%s %s", label, HelpStyle.Render(fmt.Sprintf("(%d)", m.counts[tag])))
+		if i == m.cursor {
+			s.WriteString(HighlightStyle.Render("› ") + line + "\n")
+		} else {
+			s.WriteString("  " + line + "\n")
+		}
+	}
+	s.WriteString("\n")
+
+	help := []string{
+		AccentStyle.Render("↑/↓") + ": select",
+		AccentStyle.Render("enter") + ": show",
+		AccentStyle.Render("esc") + ": back",
+	}
+	s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+
+	return s.String()
+}
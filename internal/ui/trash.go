@@ -0,0 +1,182 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/models"
+)
+
+// TrashModel represents the screen listing deleted entries
+type TrashModel struct {
+	vault      *models.Vault
+	retention  time.Duration // How long entries stay before being purged; 0 until emptied
+	cursor     int           // Index into the trash listed newest first
+	confirming string        // "purge" or "empty" while awaiting y/n
+	status     string
+}
+
+// TrashResult represents an action on the trash
+type TrashResult struct {
+	Action  string // "back", "restore", "purge", "empty"
+	EntryID string
+	Title   string
+}
+
+// NewTrashModel creates a trash screen for the vault
+func NewTrashModel(vault *models.Vault, retention time.Duration) TrashModel {
+	return TrashModel{vault: vault, retention: retention}
+}
+
+func (m TrashModel) Init() tea.Cmd {
+	return nil
+}
+
+// selected returns the entry under the cursor; the newest is listed first
+func (m TrashModel) selected() (*models.TrashedEntry, bool) {
+	if len(m.vault.Trash) == 0 {
+		return nil, false
+	}
+	return &m.vault.Trash[len(m.vault.Trash)-1-m.cursor], true
+}
+
+func (m TrashModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	if m.confirming != "" {
+		switch keyMsg.String() {
+		case "y", "Y":
+			action := m.confirming
+			m.confirming = ""
+			result := TrashResult{Action: action}
+			if trashed, ok := m.selected(); ok && action == "purge" {
+				result.EntryID, result.Title = trashed.ID, trashed.Title
+			}
+			return m, func() tea.Msg {
+				return result
+			}
+		case "n", "N", "esc":
+			m.confirming = ""
+		}
+		return m, nil
+	}
+
+	switch keyMsg.String() {
+	case "ctrl+c", "q":
+		return m, tea.Quit
+
+	case "esc", "backspace":
+		return m, func() tea.Msg {
+			return TrashResult{Action: "back"}
+		}
+
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case "down", "j":
+		if m.cursor < len(m.vault.Trash)-1 {
+			m.cursor++
+		}
+
+	case "enter", "r":
+		if trashed, ok := m.selected(); ok {
+			return m, func() tea.Msg {
+				return TrashResult{Action: "restore", EntryID: trashed.ID, Title: trashed.Title}
+			}
+		}
+
+	case "d", "x":
+		if len(m.vault.Trash) > 0 {
+			m.confirming = "purge"
+		}
+
+	case "E":
+		if len(m.vault.Trash) > 0 {
+			m.confirming = "empty"
+		}
+	}
+
+	return m, nil
+}
+
+func (m TrashModel) View() string {
+	var s strings.Builder
+
+	s.WriteString(TitleStyle.Render("trash") + "\n\n")
+
+	if len(m.vault.Trash) == 0 {
+		s.WriteString(HelpStyle.Render("the trash is empty") + "\n")
+	}
+
+	for i := range m.vault.Trash {
+		trashed := m.vault.Trash[len(m.vault.Trash)-1-i]
+		line := trashed.Schema().Icon + " " + trashed.Title
+		if path := m.vault.FolderPath(trashed.FolderID); path != "" {
+			line += HelpStyle.Render("  in " + path + models.PathSeparator)
+		}
+		line += HelpStyle.Render("  deleted " + trashed.DeletedAt.Local().Format("2006-01-02 15:04") + m.purgeNote(&trashed))
+		if i == m.cursor {
+			s.WriteString(HighlightStyle.Render("> ") + line + "\n")
+		} else {
+			s.WriteString("  " + line + "\n")
+		}
+	}
+
+	if m.status != "" {
+		s.WriteString("\n" + SuccessStyle.Render(m.status) + "\n")
+	}
+
+	s.WriteString("\n")
+	switch m.confirming {
+	case "purge":
+		trashed, _ := m.selected()
+		s.WriteString(AccentStyle.Render("delete "+trashed.Title+" for good?") + "\n")
+		s.WriteString(HelpStyle.Render("only u in the list can bring it back • y: delete • n: cancel"))
+	case "empty":
+		s.WriteString(AccentStyle.Render(fmt.Sprintf("delete all %d entries in the trash for good?", len(m.vault.Trash))) + "\n")
+		s.WriteString(HelpStyle.Render("only u in the list can bring them back • y: empty • n: cancel"))
+	default:
+		var help []string
+		if len(m.vault.Trash) > 0 {
+			help = append(help,
+				AccentStyle.Render("↑/↓")+": select",
+				AccentStyle.Render("enter")+": restore",
+				AccentStyle.Render("d")+": delete for good",
+				AccentStyle.Render("E")+": empty trash",
+			)
+		}
+		help = append(help, AccentStyle.Render("esc")+": back")
+		s.WriteString(HelpStyle.Render(strings.Join(help, " • ")))
+	}
+
+	return s.String()
+}
+
+// purgeNote says when an entry will be purged automatically
+func (m TrashModel) purgeNote(trashed *models.TrashedEntry) string {
+	purgesAt := trashed.PurgesAt(m.retention)
+	if purgesAt.IsZero() {
+		return ""
+	}
+	days := int(time.Until(purgesAt).Hours()/24) + 1
+	if days <= 1 {
+		return ", purged within a day"
+	}
+	return fmt.Sprintf(", purged in %d days", days)
+}
+
+// SetVault shows the trash of the vault as it is now, keeping the cursor in
+// range, with a status line describing the last action
+func (m TrashModel) SetVault(vault *models.Vault, status string) TrashModel {
+	m.vault = vault
+	m.status = status
+	m.cursor = max(0, min(m.cursor, len(vault.Trash)-1))
+	return m
+}
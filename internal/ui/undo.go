@@ -0,0 +1,54 @@
+package ui
+
+import "vault/internal/models"
+
+// maxUndo caps how many changes a session can take back
+const maxUndo = 100
+
+// undoStep is a change made in the TUI and what to call it in the status line
+type undoStep struct {
+	what   string
+	change models.Change
+}
+
+// undoStack holds the changes made this session, most recent last; undone
+// changes wait on redo until something new is done
+type undoStack struct {
+	done   []undoStep
+	undone []undoStep
+}
+
+// push records a new change, which makes the undone ones unreachable
+func (s undoStack) push(what string, change models.Change) undoStack {
+	if change.Empty() {
+		return s
+	}
+	s.done = append(s.done, undoStep{what: what, change: change})
+	if len(s.done) > maxUndo {
+		s.done = s.done[len(s.done)-maxUndo:]
+	}
+	s.undone = nil
+	return s
+}
+
+// undo moves the latest change onto the redo side, returning it
+func (s undoStack) undo() (undoStack, undoStep, bool) {
+	if len(s.done) == 0 {
+		return s, undoStep{}, false
+	}
+	step := s.done[len(s.done)-1]
+	s.done = s.done[:len(s.done)-1]
+	s.undone = append(s.undone, step)
+	return s, step, true
+}
+
+// redo moves the latest undone change back onto the undo side, returning it
+func (s undoStack) redo() (undoStack, undoStep, bool) {
+	if len(s.undone) == 0 {
+		return s, undoStep{}, false
+	}
+	step := s.undone[len(s.undone)-1]
+	s.undone = s.undone[:len(s.undone)-1]
+	s.done = append(s.done, step)
+	return s, step, true
+}
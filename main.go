@@ -7,6 +7,7 @@ import (
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"vault/internal/cli"
 	"vault/internal/ui"
 )
 
@@ -37,6 +38,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Dispatch subcommands; with none, launch the interactive UI
+	if flag.NArg() > 0 {
+		os.Exit(cli.Run(*vaultPath, flag.Args()))
+	}
+
 	// Create and run the application
 	app := ui.NewAppModel(*vaultPath)
 	
@@ -44,7 +50,11 @@ func main() {
 	program := tea.NewProgram(app)
 
 	// Run the program
-	if _, err := program.Run(); err != nil {
+	final, err := program.Run()
+	if model, ok := final.(ui.AppModel); ok {
+		model.Close()
+	}
+	if err != nil {
 		log.Fatal("Error running program:", err)
 	}
 }
@@ -55,14 +65,94 @@ func showHelp() {
 
 USAGE:
     vault [OPTIONS]
+    vault [OPTIONS] COMMAND [ARGS]
 
 OPTIONS:
     --vault PATH    Path to vault file (default: ~/.vault/vault.enc)
     --version       Show version information
     --help          Show this help message
 
+COMMANDS:
+    list [SEARCH]            List entries (ID, kind, title, folder, username, URL, tags)
+                             matching the search query, such as
+                             'user:alice url:github -tag:old' or 'updated:<90d',
+                             best match first
+        --query QUERY            Search query, combined with SEARCH if both are given
+    show ENTRY               Show all fields of an entry (password hidden)
+    get ENTRY                Print an entry's password; when ENTRY fits several,
+                             takes the favourite or most recently used one
+        --field NAME             Print another field: username, title, url, notes, id,
+                                 a field of the entry's kind, or a custom field
+    add --title TITLE        Add an entry
+        --kind KIND              login (default), card, identity, note, ssh_key,
+                                 wifi or database
+        --detail KEY=VALUE       Field of the kind, such as number=... for a card
+                                 or private_key=... for an SSH key (repeatable)
+        --username, --url, --notes, --password VALUE
+        --otp KEY                otpauth:// URI or base32 seed for 2FA codes
+        --tags LIST              Tags, separated by commas or spaces
+        --favorite               Rank the entry first in searches
+                                 (--favorite=false unmarks it)
+        --folder PATH            Folder such as infra/aws, created if missing
+        --field NAME[:TYPE]=VALUE
+                                 Custom field; TYPE is text, hidden, url, email
+                                 or date (repeatable)
+        --generate               Generate the password instead of prompting
+                                 (takes the generate options below)
+    edit ENTRY               Change only the fields given (same flags as add)
+        --remove-field NAME      Remove a custom field (repeatable)
+    rm ENTRY [--yes]         Move an entry to the trash
+    trash list               List deleted entries, newest first
+    trash restore ENTRY      Move a deleted entry back out of the trash
+    trash empty [ENTRY]      Delete everything in the trash, or one entry,
+                             for good (--yes skips confirmation)
+    otp ENTRY                Print the entry's current one-time (2FA) code
+    history list ENTRY       List previous passwords and other values, newest first
+    history restore ENTRY N  Put back the Nth previous value
+    generate                 Print a random password
+        --length N               Length, 8-128 (default 16)
+        --no-lower, --no-upper, --no-digits, --no-symbols
+        --exclude-ambiguous      Leave out look-alikes such as 0/O and 1/l
+        --passphrase             Random words from the EFF list instead
+        --words N                Passphrase words, 3-20 (default 6)
+        --separator S, --capitalize, --number
+    kdf show                 Show key derivation parameters
+    kdf calibrate            Pick Argon2id parameters for a target unlock time
+        --target DURATION        Target unlock time (default 1s)
+        --max-memory MIB         Memory ceiling (default 1024)
+        --parallelism N          Threads (default: CPU count, max 4)
+        --dry-run                Print without saving to config.json
+    passwd                   Change the master password and re-key the vault
+    folder list              List folders with their entry counts
+    folder add PATH          Create a folder and any missing parents
+    folder rename PATH NAME  Rename a folder
+    folder rm PATH           Delete a folder, moving its contents up a level
+    backup list              List automatic backups, newest first
+    backup restore N|NAME    Roll back to a backup (--yes skips confirmation)
+    agent                    Unlock once and keep the vault open in the background
+        --timeout DURATION       Lock after this long unused (default 15m, 0 never)
+        --foreground             Stay attached to the terminal
+    lock                     Lock the running agent
+
+    ENTRY is an entry ID, a path such as infra/aws/root, or a title; a
+    unique part of a title also works.
+    Every command accepts --format table|json|yaml; list, show and
+    history list take --reveal to include passwords in the output.
+
+EXIT CODES:
+    0 ok, 1 error, 2 usage, 3 wrong password, 4 not found,
+    5 vault locked, 6 vault corrupt, 7 ambiguous entry
+
+MASTER PASSWORD (for commands that open the vault), first match wins:
+    --password-file FILE     First line of FILE
+    --password-stdin         First line of standard input
+    VAULT_PASSWORD           Environment variable
+    otherwise                Prompted for on the terminal
+    While an agent is running, list, show, get and add use it instead
+    unless --password-file or --password-stdin is given.
+
 FEATURES:
-    • Secure AES-256-GCM encryption with PBKDF2 key derivation
+    • Secure AES-256-GCM encryption with Argon2id key derivation
     • Master password protection
     • Add, edit, delete, and search passwords
     • Secure password generation
@@ -80,14 +170,33 @@ KEYBOARD SHORTCUTS:
     Password Management:
         n             Add new password
         e             Edit selected password
-        d             Delete selected password
+        d             Move selected password to the trash
         c             Copy password to clipboard
-        /             Search passwords
+        o             Copy one-time (2FA) code
+        /             Search passwords, best match first
+        *             Mark or unmark the selected entry as a favourite
+        t             Show only entries with a tag (Esc shows all again)
+        →/←           Expand / collapse the selected folder
+        m             Move the selected entry to a folder
+        F             Create a folder in the selected one
+        R             Rename the selected folder (d deletes it)
+        P             Change master password
+        B             Browse and restore backups
+        T             Open the trash to restore or purge deleted entries
+        u / Ctrl+R    Undo / redo the last change made since unlocking
+        L             Lock the vault now (list and details)
+        h             Previous passwords and other values (details;
+                      r restores the selected one)
 
     Form Actions:
         Ctrl+S        Save password entry
-        Ctrl+G        Generate random password
-        Ctrl+H        Toggle password visibility
+        Ctrl+K        Change the kind of a new entry (login, card, ...)
+        Ctrl+G        Open the password generator
+        Ctrl+H        Toggle password and hidden field visibility
+        Ctrl+N        Add a custom field
+        Ctrl+T        Change the focused custom field's type
+        Ctrl+O        Conceal or show the focused custom field
+        Ctrl+X        Remove the focused custom field
 
 FIRST RUN:
     On first run, you'll be prompted to create a master password.
@@ -95,13 +204,23 @@ FIRST RUN:
 
 SECURITY:
     • Passwords are encrypted with AES-256-GCM
-    • Master password is processed with PBKDF2 (100,000 iterations)
+    • Master password is processed with Argon2id (64 MiB, 3 passes by default)
+    • Vaults created by older versions keep PBKDF2 (100,000 iterations)
     • Vault file is only readable by the owner (permissions 0600)
+    • Only one process can modify the vault at a time; others open read-only
+    • The master password is not kept; only the derived key, wiped on exit
+    • The interface locks after 5 minutes without a keypress
+    • Copied passwords are cleared from the clipboard after 30 seconds
     • Sensitive data is cleared from memory when possible
 
 EXAMPLES:
     vault                           # Use default vault location
     vault --vault /path/to/my.enc   # Use custom vault file
+    vault get github --field username
+    vault add --kind card --title visa --detail number=4111111111111111
+    vault generate --passphrase --words 5
+    VAULT_PASSWORD=... vault list   # Non-interactive use in scripts
+    vault kdf calibrate --target 2s # Tune Argon2id for new vaults
     vault --version                 # Show version
     vault --help                    # Show this help
 